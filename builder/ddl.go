@@ -0,0 +1,455 @@
+package builder
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	ksql "github.com/vancelongwill/ksql-go/client"
+	"github.com/vancelongwill/ksql-go/ksqltypes"
+	"github.com/vancelongwill/ksql-go/lexer"
+)
+
+// Statement is a statement which can be rendered as SQL
+type Statement interface {
+	ToSQL() (string, error)
+}
+
+// Exec renders a statement and runs it with Client.Exec. Queries are rejected, as they must be run with Query or QueryStream.
+func Exec(ctx context.Context, c ksql.Client, stmt Statement) ([]ksql.ExecResult, error) {
+	q, err := stmt.ToSQL()
+	if err != nil {
+		return nil, err
+	}
+	kind, err := lexer.Classify(q)
+	if err != nil {
+		return nil, err
+	}
+	if kind.IsQuery() {
+		return nil, fmt.Errorf("%w: %s must be run with Query or QueryStream", ErrInvalidStatement, kind)
+	}
+	return c.Exec(ctx, ksql.ExecPayload{KSQL: q})
+}
+
+// Format is the serialization format of the key or value of a source
+type Format string
+
+// Serialization formats supported by ksqlDB
+const (
+	JSON         Format = "JSON"
+	JSONSR       Format = "JSON_SR"
+	Avro         Format = "AVRO"
+	Protobuf     Format = "PROTOBUF"
+	ProtobufNoSR Format = "PROTOBUF_NOSR"
+	Delimited    Format = "DELIMITED"
+	Kafka        Format = "KAFKA"
+	None         Format = "NONE"
+)
+
+// Properties are the WITH properties of a CREATE statement. Zero values are omitted.
+type Properties struct {
+	// KafkaTopic is the topic backing the source, which is required unless the source is created AS SELECT
+	KafkaTopic string
+	// Partitions is the number of partitions to create the topic with, if it doesn't exist
+	Partitions int
+	// Replicas is the replication factor to create the topic with, if it doesn't exist
+	Replicas int
+	// Format sets both the key and value formats, and can't be combined with KeyFormat or ValueFormat
+	Format Format
+	// KeyFormat is the serialization format of the key
+	KeyFormat Format
+	// ValueFormat is the serialization format of the value
+	ValueFormat Format
+	// ValueDelimiter is the delimiter of DELIMITED values
+	ValueDelimiter string
+	// Timestamp is the column holding the timestamp of each row, instead of the Kafka record timestamp
+	Timestamp string
+	// TimestampFormat is the format of the Timestamp column when it's a string, and requires Timestamp
+	TimestampFormat string
+	// WrapSingleValue controls whether a value with a single column is wrapped in an object, and is only valid when the value has one column
+	WrapSingleValue *bool
+}
+
+// validate checks combinations of properties, given the number of value columns or -1 if it isn't known
+func (p Properties) validate(valueColumns int) error {
+	switch {
+	case p.Format != "" && (p.KeyFormat != "" || p.ValueFormat != ""):
+		return fmt.Errorf("%w: FORMAT can't be combined with KEY_FORMAT or VALUE_FORMAT", ErrInvalidStatement)
+	case p.Partitions < 0 || p.Replicas < 0:
+		return fmt.Errorf("%w: PARTITIONS and REPLICAS must be positive", ErrInvalidStatement)
+	case p.TimestampFormat != "" && p.Timestamp == "":
+		return fmt.Errorf("%w: TIMESTAMP_FORMAT requires TIMESTAMP", ErrInvalidStatement)
+	case p.ValueDelimiter != "" && p.valueFormat() != Delimited:
+		return fmt.Errorf("%w: VALUE_DELIMITER requires the DELIMITED value format", ErrInvalidStatement)
+	case p.WrapSingleValue != nil && valueColumns > 1:
+		return fmt.Errorf("%w: WRAP_SINGLE_VALUE requires a single value column", ErrInvalidStatement)
+	}
+	return nil
+}
+
+func (p Properties) valueFormat() Format {
+	if p.Format != "" {
+		return p.Format
+	}
+	return p.ValueFormat
+}
+
+func (p Properties) keyFormat() Format {
+	if p.Format != "" {
+		return p.Format
+	}
+	return p.KeyFormat
+}
+
+// inferred reports whether the columns of a format can be inferred from the schema registry, so that they needn't be declared
+func (f Format) inferred() bool {
+	switch f {
+	case Avro, Protobuf, JSONSR:
+		return true
+	}
+	return false
+}
+
+// render renders the properties as a WITH clause, or an empty string if there are none
+func (p Properties) render() string {
+	var props []string
+	add := func(name, value string) {
+		props = append(props, name+"="+value)
+	}
+	if p.KafkaTopic != "" {
+		add("KAFKA_TOPIC", ksqltypes.QuoteString(p.KafkaTopic))
+	}
+	if p.Partitions > 0 {
+		add("PARTITIONS", strconv.Itoa(p.Partitions))
+	}
+	if p.Replicas > 0 {
+		add("REPLICAS", strconv.Itoa(p.Replicas))
+	}
+	if p.Format != "" {
+		add("FORMAT", ksqltypes.QuoteString(string(p.Format)))
+	}
+	if p.KeyFormat != "" {
+		add("KEY_FORMAT", ksqltypes.QuoteString(string(p.KeyFormat)))
+	}
+	if p.ValueFormat != "" {
+		add("VALUE_FORMAT", ksqltypes.QuoteString(string(p.ValueFormat)))
+	}
+	if p.ValueDelimiter != "" {
+		add("VALUE_DELIMITER", ksqltypes.QuoteString(p.ValueDelimiter))
+	}
+	if p.Timestamp != "" {
+		add("TIMESTAMP", ksqltypes.QuoteString(p.Timestamp))
+	}
+	if p.TimestampFormat != "" {
+		add("TIMESTAMP_FORMAT", ksqltypes.QuoteString(p.TimestampFormat))
+	}
+	if p.WrapSingleValue != nil {
+		add("WRAP_SINGLE_VALUE", strings.ToUpper(strconv.FormatBool(*p.WrapSingleValue)))
+	}
+	if len(props) == 0 {
+		return ""
+	}
+	return " WITH (" + strings.Join(props, ", ") + ")"
+}
+
+// ColumnDef is a column definition of a CREATE statement
+type ColumnDef struct {
+	// Name is the name of the column, see Identifier
+	Name string
+	// Type is the ksqlDB type of the column, e.g. STRING or ARRAY<INT>
+	Type string
+	// Key marks a key column, rendered as KEY for streams and PRIMARY KEY for tables
+	Key bool
+}
+
+// CreateBuilder builds a CREATE STREAM or CREATE TABLE statement, either with a list of columns or AS SELECT
+type CreateBuilder struct {
+	kind        string
+	name        string
+	orReplace   bool
+	ifNotExists bool
+	columns     []ColumnDef
+	props       Properties
+	query       *SelectBuilder
+	err         error
+}
+
+// CreateStream starts a CREATE STREAM statement
+func CreateStream(name string) *CreateBuilder {
+	return &CreateBuilder{kind: "STREAM", name: name}
+}
+
+// CreateTable starts a CREATE TABLE statement
+func CreateTable(name string) *CreateBuilder {
+	return &CreateBuilder{kind: "TABLE", name: name}
+}
+
+// OrReplace replaces the source if it already exists
+func (c *CreateBuilder) OrReplace() *CreateBuilder {
+	c.orReplace = true
+	return c
+}
+
+// IfNotExists leaves the source as it is if it already exists
+func (c *CreateBuilder) IfNotExists() *CreateBuilder {
+	c.ifNotExists = true
+	return c
+}
+
+// Column adds a value column of a ksqlDB type, e.g. STRING or ARRAY<INT>
+func (c *CreateBuilder) Column(name, typ string) *CreateBuilder {
+	c.columns = append(c.columns, ColumnDef{Name: name, Type: typ})
+	return c
+}
+
+// Key adds a key column, which is rendered as KEY for streams and PRIMARY KEY for tables
+func (c *CreateBuilder) Key(name, typ string) *CreateBuilder {
+	c.columns = append(c.columns, ColumnDef{Name: name, Type: typ, Key: true})
+	return c
+}
+
+// Columns adds column definitions, such as those returned by Schema
+func (c *CreateBuilder) Columns(defs ...ColumnDef) *CreateBuilder {
+	c.columns = append(c.columns, defs...)
+	return c
+}
+
+// With sets the WITH properties
+func (c *CreateBuilder) With(props Properties) *CreateBuilder {
+	c.props = props
+	return c
+}
+
+// AsSelect creates the source from the results of a query, which starts a persistent query
+func (c *CreateBuilder) AsSelect(q *SelectBuilder) *CreateBuilder {
+	c.query = q
+	return c
+}
+
+// ToSQL validates and renders the statement, including a trailing semicolon
+func (c *CreateBuilder) ToSQL() (string, error) {
+	if err := c.validate(); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString("CREATE ")
+	if c.orReplace {
+		b.WriteString("OR REPLACE ")
+	}
+	b.WriteString(c.kind)
+	if c.ifNotExists {
+		b.WriteString(" IF NOT EXISTS")
+	}
+	b.WriteString(" ")
+	b.WriteString(Identifier(c.name))
+	if len(c.columns) > 0 {
+		defs := make([]string, len(c.columns))
+		for i, col := range c.columns {
+			defs[i] = Identifier(col.Name) + " " + col.Type
+			switch {
+			case col.Key && c.kind == "TABLE":
+				defs[i] += " PRIMARY KEY"
+			case col.Key:
+				defs[i] += " KEY"
+			}
+		}
+		b.WriteString(" (")
+		b.WriteString(strings.Join(defs, ", "))
+		b.WriteString(")")
+	}
+	b.WriteString(c.props.render())
+	if c.query != nil {
+		q, err := c.query.render()
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(" AS ")
+		b.WriteString(q)
+	}
+	b.WriteString(";")
+	return b.String(), nil
+}
+
+func (c *CreateBuilder) validate() error {
+	if c.err != nil {
+		return c.err
+	}
+	if c.name == "" {
+		return fmt.Errorf("%w: the %s must have a name", ErrInvalidStatement, strings.ToLower(c.kind))
+	}
+	if c.orReplace && c.ifNotExists {
+		return fmt.Errorf("%w: OR REPLACE can't be combined with IF NOT EXISTS", ErrInvalidStatement)
+	}
+	if c.query != nil {
+		if len(c.columns) > 0 {
+			return fmt.Errorf("%w: columns can't be declared AS SELECT", ErrInvalidStatement)
+		}
+		return c.props.validate(-1)
+	}
+	if len(c.columns) == 0 && !c.props.valueFormat().inferred() {
+		return fmt.Errorf("%w: the %s must have columns, be created AS SELECT or have a value format using the schema registry", ErrInvalidStatement, strings.ToLower(c.kind))
+	}
+	if c.props.KafkaTopic == "" {
+		return fmt.Errorf("%w: KAFKA_TOPIC is required", ErrInvalidStatement)
+	}
+	var keys, values int
+	for _, col := range c.columns {
+		if err := validateType(col.Type); err != nil {
+			return fmt.Errorf("column %s: %w", col.Name, err)
+		}
+		if col.Key {
+			keys++
+		} else {
+			values++
+		}
+	}
+	if c.kind == "TABLE" && keys == 0 && !c.props.keyFormat().inferred() {
+		return fmt.Errorf("%w: a table must have a PRIMARY KEY column", ErrInvalidStatement)
+	}
+	return c.props.validate(values)
+}
+
+// validateType checks that a column type is a single type expression with balanced brackets, so that it can't inject other clauses or statements
+func validateType(typ string) error {
+	tokens, err := lexer.Tokenize(typ)
+	if err != nil {
+		return err
+	}
+	if len(lexer.Significant(tokens)) == 0 {
+		return fmt.Errorf("%w: missing type", ErrInvalidStatement)
+	}
+	invalid := fmt.Errorf("%w: invalid type %q", ErrInvalidStatement, typ)
+	// closers holds the brackets which are still open, innermost last
+	var closers []rune
+	for _, tok := range tokens {
+		if tok.Kind == lexer.Semicolon || tok.Kind == lexer.Comment {
+			return invalid
+		}
+		if tok.Kind != lexer.Operator {
+			continue
+		}
+		for _, r := range tok.Text {
+			switch r {
+			case '(':
+				closers = append(closers, ')')
+			case '<':
+				closers = append(closers, '>')
+			case ')', '>':
+				if len(closers) == 0 || closers[len(closers)-1] != r {
+					return invalid
+				}
+				closers = closers[:len(closers)-1]
+			case ',':
+				// a comma outside of brackets would start another column or clause
+				if len(closers) == 0 {
+					return invalid
+				}
+			}
+		}
+	}
+	if len(closers) > 0 {
+		return invalid
+	}
+	return nil
+}
+
+// DropBuilder builds a DROP STREAM or DROP TABLE statement
+type DropBuilder struct {
+	kind        string
+	name        string
+	ifExists    bool
+	deleteTopic bool
+}
+
+// DropStream starts a DROP STREAM statement
+func DropStream(name string) *DropBuilder {
+	return &DropBuilder{kind: "STREAM", name: name}
+}
+
+// DropTable starts a DROP TABLE statement
+func DropTable(name string) *DropBuilder {
+	return &DropBuilder{kind: "TABLE", name: name}
+}
+
+// IfExists doesn't fail if the source doesn't exist
+func (d *DropBuilder) IfExists() *DropBuilder {
+	d.ifExists = true
+	return d
+}
+
+// DeleteTopic also deletes the topic backing the source
+func (d *DropBuilder) DeleteTopic() *DropBuilder {
+	d.deleteTopic = true
+	return d
+}
+
+// ToSQL renders the statement, including a trailing semicolon
+func (d *DropBuilder) ToSQL() (string, error) {
+	if d.name == "" {
+		return "", fmt.Errorf("%w: the %s must have a name", ErrInvalidStatement, strings.ToLower(d.kind))
+	}
+	var b strings.Builder
+	b.WriteString("DROP ")
+	b.WriteString(d.kind)
+	if d.ifExists {
+		b.WriteString(" IF EXISTS")
+	}
+	b.WriteString(" ")
+	b.WriteString(Identifier(d.name))
+	if d.deleteTopic {
+		b.WriteString(" DELETE TOPIC")
+	}
+	b.WriteString(";")
+	return b.String(), nil
+}
+
+// InsertBuilder builds an INSERT INTO ... SELECT statement, which starts a persistent query writing to an existing stream
+type InsertBuilder struct {
+	target  string
+	queryID string
+	query   *SelectBuilder
+}
+
+// InsertInto starts an INSERT INTO ... SELECT statement
+func InsertInto(target string) *InsertBuilder {
+	return &InsertBuilder{target: target}
+}
+
+// QueryID sets the ID of the persistent query, instead of the generated one
+func (i *InsertBuilder) QueryID(id string) *InsertBuilder {
+	i.queryID = id
+	return i
+}
+
+// AsSelect sets the query whose results are inserted
+func (i *InsertBuilder) AsSelect(q *SelectBuilder) *InsertBuilder {
+	i.query = q
+	return i
+}
+
+// ToSQL validates and renders the statement, including a trailing semicolon
+func (i *InsertBuilder) ToSQL() (string, error) {
+	if i.target == "" {
+		return "", fmt.Errorf("%w: INSERT INTO must have a target", ErrInvalidStatement)
+	}
+	if i.query == nil {
+		return "", fmt.Errorf("%w: INSERT INTO must have a query", ErrInvalidStatement)
+	}
+	q, err := i.query.render()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString("INSERT INTO ")
+	b.WriteString(Identifier(i.target))
+	if i.queryID != "" {
+		b.WriteString(" WITH (QUERY_ID=")
+		b.WriteString(ksqltypes.QuoteString(i.queryID))
+		b.WriteString(")")
+	}
+	b.WriteString(" ")
+	b.WriteString(q)
+	b.WriteString(";")
+	return b.String(), nil
+}
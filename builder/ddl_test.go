@@ -0,0 +1,140 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	ksql "github.com/vancelongwill/ksql-go/client"
+	"github.com/vancelongwill/ksql-go/lexer"
+	"github.com/vancelongwill/ksql-go/stdlib/mocks"
+)
+
+func TestCreate(t *testing.T) {
+	t.Run("it should render a stream with columns and properties", func(t *testing.T) {
+		got, err := CreateStream("s1").
+			Key("k", "VARCHAR").
+			Column("v1", "INT").
+			Column("tags", "ARRAY<STRING>").
+			Column("items", "MAP<STRING, ARRAY<STRUCT<price DECIMAL(10, 2)>>>").
+			With(Properties{KafkaTopic: "s1", Partitions: 1, ValueFormat: Avro}).
+			ToSQL()
+		assert.NoError(t, err)
+		assert.Equal(t, "CREATE STREAM s1 (k VARCHAR KEY, v1 INT, tags ARRAY<STRING>, items MAP<STRING, ARRAY<STRUCT<price DECIMAL(10, 2)>>>) WITH (KAFKA_TOPIC='s1', PARTITIONS=1, VALUE_FORMAT='AVRO');", got)
+	})
+
+	t.Run("it should render a table with a primary key", func(t *testing.T) {
+		wrap := false
+		got, err := CreateTable("t1").
+			IfNotExists().
+			Key("id", "BIGINT").
+			Column("name", "STRING").
+			With(Properties{KafkaTopic: "users", Format: JSON, WrapSingleValue: &wrap}).
+			ToSQL()
+		assert.NoError(t, err)
+		assert.Equal(t, "CREATE TABLE IF NOT EXISTS t1 (id BIGINT PRIMARY KEY, name STRING) WITH (KAFKA_TOPIC='users', FORMAT='JSON', WRAP_SINGLE_VALUE=FALSE);", got)
+	})
+
+	t.Run("it should render sources whose columns are inferred from the schema registry", func(t *testing.T) {
+		for format, want := range map[Format]string{
+			Avro:     "CREATE STREAM s1 WITH (KAFKA_TOPIC='s1', VALUE_FORMAT='AVRO');",
+			Protobuf: "CREATE STREAM s1 WITH (KAFKA_TOPIC='s1', VALUE_FORMAT='PROTOBUF');",
+			JSONSR:   "CREATE STREAM s1 WITH (KAFKA_TOPIC='s1', VALUE_FORMAT='JSON_SR');",
+		} {
+			got, err := CreateStream("s1").With(Properties{KafkaTopic: "s1", ValueFormat: format}).ToSQL()
+			assert.NoError(t, err)
+			assert.Equal(t, want, got)
+		}
+		got, err := CreateTable("t1").With(Properties{KafkaTopic: "t1", Format: Avro}).ToSQL()
+		assert.NoError(t, err)
+		assert.Equal(t, "CREATE TABLE t1 WITH (KAFKA_TOPIC='t1', FORMAT='AVRO');", got)
+		got, err = CreateTable("t1").Key("id", "BIGINT").With(Properties{KafkaTopic: "t1", ValueFormat: Protobuf}).ToSQL()
+		assert.NoError(t, err)
+		assert.Equal(t, "CREATE TABLE t1 (id BIGINT PRIMARY KEY) WITH (KAFKA_TOPIC='t1', VALUE_FORMAT='PROTOBUF');", got)
+	})
+
+	t.Run("it should render a table AS SELECT", func(t *testing.T) {
+		got, err := CreateTable("t1").
+			OrReplace().
+			With(Properties{Partitions: 3, Timestamp: "ts", TimestampFormat: "yyyy-MM-dd"}).
+			AsSelect(Select("k").Column("LATEST_BY_OFFSET(v1)", "v1").From("s1").GroupBy("k").EmitChanges()).
+			ToSQL()
+		assert.NoError(t, err)
+		assert.Equal(t, "CREATE OR REPLACE TABLE t1 WITH (PARTITIONS=3, TIMESTAMP='ts', TIMESTAMP_FORMAT='yyyy-MM-dd') AS SELECT k, LATEST_BY_OFFSET(v1) AS v1 FROM s1 GROUP BY k EMIT CHANGES;", got)
+		kind, err := lexer.Classify(got)
+		assert.NoError(t, err)
+		assert.Equal(t, lexer.DDL, kind)
+	})
+
+	t.Run("it should reject invalid combinations", func(t *testing.T) {
+		wrap := true
+		topic := Properties{KafkaTopic: "s1"}
+		for name, stmt := range map[string]Statement{
+			"no columns":                         CreateStream("s1").With(topic),
+			"no columns without schema registry": CreateStream("s1").With(Properties{KafkaTopic: "s1", ValueFormat: ProtobufNoSR}),
+			"no topic with inferred columns":     CreateStream("s1").With(Properties{ValueFormat: Avro}),
+			"table with an inferred value only":  CreateTable("t1").With(Properties{KafkaTopic: "t1", ValueFormat: Avro}),
+			"no topic":                           CreateStream("s1").Column("v", "INT"),
+			"table without a primary key":        CreateTable("t1").Column("v", "INT").With(topic),
+			"columns AS SELECT":                  CreateStream("s2").Column("v", "INT").AsSelect(Select("*").From("s1")),
+			"OR REPLACE and IF NOT EXISTS":       CreateStream("s1").OrReplace().IfNotExists().Column("v", "INT").With(topic),
+			"FORMAT and VALUE_FORMAT":            CreateStream("s1").Column("v", "INT").With(Properties{KafkaTopic: "s1", Format: JSON, ValueFormat: Avro}),
+			"TIMESTAMP_FORMAT alone":             CreateStream("s1").Column("v", "INT").With(Properties{KafkaTopic: "s1", TimestampFormat: "yyyy"}),
+			"VALUE_DELIMITER without DELIMITED":  CreateStream("s1").Column("v", "INT").With(Properties{KafkaTopic: "s1", ValueDelimiter: "|"}),
+			"WRAP_SINGLE_VALUE with two values":  CreateStream("s1").Column("a", "INT").Column("b", "INT").With(Properties{KafkaTopic: "s1", WrapSingleValue: &wrap}),
+			"a type injecting a statement":       CreateStream("s1").Column("v", "INT); DROP STREAM s2; --").With(topic),
+			"a type closing the column list":     CreateStream("s1").Column("v", "INT) WITH (KAFKA_TOPIC='x'").With(topic),
+			"a type adding a column":             CreateStream("s1").Column("v", "INT, w STRING").With(topic),
+			"a type with an unclosed bracket":    CreateStream("s1").Column("v", "ARRAY<INT").With(topic),
+			"an invalid query":                   CreateStream("s2").AsSelect(Select("*")),
+		} {
+			_, err := stmt.ToSQL()
+			assert.True(t, errors.Is(err, ErrInvalidStatement), "%s: %v", name, err)
+		}
+	})
+}
+
+func TestDrop(t *testing.T) {
+	got, err := DropTable("t1").IfExists().DeleteTopic().ToSQL()
+	assert.NoError(t, err)
+	assert.Equal(t, "DROP TABLE IF EXISTS t1 DELETE TOPIC;", got)
+	got, err = DropStream("my stream").ToSQL()
+	assert.NoError(t, err)
+	assert.Equal(t, "DROP STREAM `my stream`;", got)
+}
+
+func TestInsertInto(t *testing.T) {
+	got, err := InsertInto("s2").QueryID("copy").AsSelect(Select("*").From("s1").Where("v > ?", 1).EmitChanges()).ToSQL()
+	assert.NoError(t, err)
+	assert.Equal(t, "INSERT INTO s2 WITH (QUERY_ID='copy') SELECT * FROM s1 WHERE v > 1 EMIT CHANGES;", got)
+	_, err = InsertInto("s2").ToSQL()
+	assert.True(t, errors.Is(err, ErrInvalidStatement))
+}
+
+func TestExec(t *testing.T) {
+	t.Run("it should execute a valid statement", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		c := mocks.NewMockClient(ctrl)
+		ctx := context.Background()
+		c.EXPECT().Exec(ctx, ksql.ExecPayload{KSQL: "DROP STREAM s1;"}).Return(nil, nil)
+		_, err := Exec(ctx, c, DropStream("s1"))
+		assert.NoError(t, err)
+	})
+	t.Run("it should not execute an invalid statement", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		c := mocks.NewMockClient(ctrl)
+		_, err := Exec(context.Background(), c, CreateStream("s1"))
+		assert.True(t, errors.Is(err, ErrInvalidStatement))
+	})
+	t.Run("it should not execute a query", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		c := mocks.NewMockClient(ctrl)
+		_, err := Exec(context.Background(), c, Select("*").From("t1"))
+		assert.True(t, errors.Is(err, ErrInvalidStatement))
+	})
+}
@@ -0,0 +1,2 @@
+// Package builder renders ksqlDB statements from Go, escaping identifiers and binding arguments as literals
+package builder
@@ -0,0 +1,148 @@
+package builder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/vancelongwill/ksql-go/internal/tags"
+)
+
+// ErrUnsupportedType is returned when a Go type has no equivalent ksqlDB type
+var ErrUnsupportedType = errors.New("unsupported type")
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	jsonNumberType = reflect.TypeOf(json.Number(""))
+	bytesType      = reflect.TypeOf([]byte(nil))
+)
+
+// Schema reflects a struct into column definitions, one for each exported field.
+//
+// Columns are named by the `ksql` tag, or the field name, and fields tagged "-" are skipped, following the same rules as the client. The options after the name in the tag are:
+//
+//	key           marks a key column, e.g. `ksql:"id,key"`
+//	precision=N   makes a number a DECIMAL with the precision N
+//	scale=N       sets the scale of a DECIMAL, defaulting to 0
+//
+// Go types map to ksqlDB types as follows: string is STRING, bool is BOOLEAN, integers of up to 32 bits are INTEGER, int, int64 and uint32 are BIGINT, floats are DOUBLE, []byte is BYTES, time.Time is TIMESTAMP, slices and arrays are ARRAY, maps with string keys are MAP and structs are STRUCT. Pointers map to the type they point to.
+// Embedded structs without a name in their tag are flattened, except for time.Time.
+func Schema(v interface{}) ([]ColumnDef, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: expected a struct but got %v", ErrUnsupportedType, t)
+	}
+	fields, err := tags.Fields(t)
+	if err != nil {
+		return nil, err
+	}
+	defs := make([]ColumnDef, len(fields))
+	for i, f := range fields {
+		typ, err := typeOf(f.Type, f, nil)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		defs[i] = ColumnDef{Name: f.Name, Type: typ, Key: f.Key}
+	}
+	return defs, nil
+}
+
+// CreateStreamOf starts a CREATE STREAM statement with the columns of a struct, see Schema
+func CreateStreamOf(name string, v interface{}) *CreateBuilder {
+	return CreateStream(name).columnsOf(v)
+}
+
+// CreateTableOf starts a CREATE TABLE statement with the columns of a struct, see Schema
+func CreateTableOf(name string, v interface{}) *CreateBuilder {
+	return CreateTable(name).columnsOf(v)
+}
+
+func (c *CreateBuilder) columnsOf(v interface{}) *CreateBuilder {
+	defs, err := Schema(v)
+	if err != nil {
+		c.err = err
+		return c
+	}
+	return c.Columns(defs...)
+}
+
+// typeOf returns the ksqlDB type of a Go type, where seen holds the structs being converted so that recursive types are rejected
+func typeOf(t reflect.Type, tag tags.Field, seen map[reflect.Type]bool) (string, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if tag.Precision > 0 {
+		switch t.Kind() {
+		case reflect.Float32, reflect.Float64, reflect.String:
+			if tag.Scale < 0 || tag.Scale > tag.Precision {
+				return "", fmt.Errorf("%w: scale %d must be between 0 and the precision %d", ErrInvalidStatement, tag.Scale, tag.Precision)
+			}
+			return fmt.Sprintf("DECIMAL(%d, %d)", tag.Precision, tag.Scale), nil
+		}
+		return "", fmt.Errorf("%w: precision requires a float or string field but got %s", ErrUnsupportedType, t)
+	}
+	switch t {
+	case timeType:
+		return "TIMESTAMP", nil
+	case jsonNumberType:
+		return "DOUBLE", nil
+	case bytesType:
+		return "BYTES", nil
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "STRING", nil
+	case reflect.Bool:
+		return "BOOLEAN", nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return "INTEGER", nil
+	case reflect.Int, reflect.Int64, reflect.Uint32:
+		return "BIGINT", nil
+	case reflect.Float32, reflect.Float64:
+		return "DOUBLE", nil
+	case reflect.Slice, reflect.Array:
+		elem, err := typeOf(t.Elem(), tags.Field{}, seen)
+		if err != nil {
+			return "", err
+		}
+		return "ARRAY<" + elem + ">", nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return "", fmt.Errorf("%w: the keys of %s must be strings", ErrUnsupportedType, t)
+		}
+		value, err := typeOf(t.Elem(), tags.Field{}, seen)
+		if err != nil {
+			return "", err
+		}
+		return "MAP<STRING, " + value + ">", nil
+	case reflect.Struct:
+		if seen[t] {
+			return "", fmt.Errorf("%w: %s is recursive", ErrUnsupportedType, t)
+		}
+		if seen == nil {
+			seen = make(map[reflect.Type]bool)
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		fields, err := tags.Fields(t)
+		if err != nil {
+			return "", err
+		}
+		defs := make([]string, len(fields))
+		for i, f := range fields {
+			typ, err := typeOf(f.Type, f, seen)
+			if err != nil {
+				return "", fmt.Errorf("field %s: %w", f.Name, err)
+			}
+			defs[i] = Identifier(f.Name) + " " + typ
+		}
+		return "STRUCT<" + strings.Join(defs, ", ") + ">", nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+}
@@ -0,0 +1,86 @@
+package builder
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Audit struct {
+	CreatedAt time.Time `ksql:"created_at"`
+}
+
+type order struct {
+	Audit
+	ID       string                `ksql:"id,key"`
+	Quantity int32                 `ksql:"quantity"`
+	Price    float64               `ksql:"price,precision=10,scale=2"`
+	Total    *float64              `ksql:"total"`
+	Paid     bool                  `ksql:"paid"`
+	Tags     []string              `ksql:"tags"`
+	Extra    map[string]int64      `ksql:"extra"`
+	Payload  []byte                `ksql:"payload"`
+	Shipping struct{ City string } `ksql:"shipping"`
+	Notes    string                `ksql:"-"`
+	internal string
+}
+
+type node struct {
+	Next *node
+}
+
+func TestSchema(t *testing.T) {
+	t.Run("it should reflect a struct into columns", func(t *testing.T) {
+		got, err := Schema(&order{})
+		assert.NoError(t, err)
+		assert.Equal(t, []ColumnDef{
+			{Name: "created_at", Type: "TIMESTAMP"},
+			{Name: "id", Type: "STRING", Key: true},
+			{Name: "quantity", Type: "INTEGER"},
+			{Name: "price", Type: "DECIMAL(10, 2)"},
+			{Name: "total", Type: "DOUBLE"},
+			{Name: "paid", Type: "BOOLEAN"},
+			{Name: "tags", Type: "ARRAY<STRING>"},
+			{Name: "extra", Type: "MAP<STRING, BIGINT>"},
+			{Name: "payload", Type: "BYTES"},
+			{Name: "shipping", Type: "STRUCT<City STRING>"},
+		}, got)
+	})
+
+	t.Run("it should produce the DDL for a table", func(t *testing.T) {
+		got, err := CreateTableOf("orders", order{}).With(Properties{KafkaTopic: "orders", ValueFormat: JSON}).ToSQL()
+		assert.NoError(t, err)
+		assert.Equal(t, "CREATE TABLE orders (created_at TIMESTAMP, id STRING PRIMARY KEY, quantity INTEGER, price DECIMAL(10, 2), total DOUBLE, paid BOOLEAN, tags ARRAY<STRING>, extra MAP<STRING, BIGINT>, payload BYTES, shipping STRUCT<City STRING>) WITH (KAFKA_TOPIC='orders', VALUE_FORMAT='JSON');", got)
+	})
+
+	t.Run("it should fail for types without an equivalent", func(t *testing.T) {
+		for name, v := range map[string]interface{}{
+			"not a struct":   "abc",
+			"channel":        struct{ C chan int }{},
+			"unsigned int":   struct{ N uint64 }{},
+			"map int keys":   struct{ M map[int32]string }{},
+			"recursive type": node{},
+		} {
+			_, err := Schema(v)
+			assert.True(t, errors.Is(err, ErrUnsupportedType), "%s: %v", name, err)
+		}
+	})
+
+	t.Run("it should fail for invalid tags", func(t *testing.T) {
+		_, err := Schema(struct {
+			N int `ksql:"n,unknown"`
+		}{})
+		assert.Error(t, err)
+		_, err = Schema(struct {
+			N float64 `ksql:"n,precision=2,scale=3"`
+		}{})
+		assert.True(t, errors.Is(err, ErrInvalidStatement))
+	})
+
+	t.Run("it should report schema errors when the DDL is rendered", func(t *testing.T) {
+		_, err := CreateStreamOf("s1", 1).With(Properties{KafkaTopic: "s1"}).ToSQL()
+		assert.True(t, errors.Is(err, ErrUnsupportedType))
+	})
+}
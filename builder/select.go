@@ -0,0 +1,243 @@
+package builder
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	ksql "github.com/vancelongwill/ksql-go/client"
+)
+
+// SelectBuilder builds a pull or push query, keeping any error until ToSQL is called
+type SelectBuilder struct {
+	columns []string
+	from    string
+	joins   []string
+	window  string
+	where   []string
+	groupBy []string
+	having  []string
+	emit    bool
+	limit   int
+	err     error
+}
+
+// Select starts a query selecting the named columns, see Identifier. Use "*" to select every column, and Column for expressions.
+func Select(columns ...string) *SelectBuilder {
+	s := &SelectBuilder{}
+	for _, col := range columns {
+		s.columns = append(s.columns, Identifier(col))
+	}
+	return s
+}
+
+// Column selects an expression, such as COUNT(*) or s->field, optionally naming it with an alias. Any ? placeholders in the expression are replaced with the literals of args.
+func (s *SelectBuilder) Column(expr string, alias string, args ...interface{}) *SelectBuilder {
+	col, err := bind(expr, args)
+	if err != nil {
+		return s.fail(err)
+	}
+	if alias != "" {
+		col += " AS " + Identifier(alias)
+	}
+	s.columns = append(s.columns, col)
+	return s
+}
+
+// From sets the stream or table to query
+func (s *SelectBuilder) From(source string) *SelectBuilder {
+	s.from = Identifier(source)
+	return s
+}
+
+// Join adds an inner join with another stream or table on the condition, in which ? placeholders are replaced with the literals of args
+func (s *SelectBuilder) Join(source string, on string, args ...interface{}) *SelectBuilder {
+	return s.join("INNER JOIN", source, on, args)
+}
+
+// LeftJoin adds a left outer join with another stream or table, see Join
+func (s *SelectBuilder) LeftJoin(source string, on string, args ...interface{}) *SelectBuilder {
+	return s.join("LEFT JOIN", source, on, args)
+}
+
+// FullJoin adds a full outer join with another stream or table, see Join
+func (s *SelectBuilder) FullJoin(source string, on string, args ...interface{}) *SelectBuilder {
+	return s.join("FULL OUTER JOIN", source, on, args)
+}
+
+func (s *SelectBuilder) join(kind, source, on string, args []interface{}) *SelectBuilder {
+	cond, err := bind(on, args)
+	if err != nil {
+		return s.fail(err)
+	}
+	s.joins = append(s.joins, fmt.Sprintf("%s %s ON %s", kind, Identifier(source), cond))
+	return s
+}
+
+// WindowTumbling groups rows into fixed size windows which don't overlap
+func (s *SelectBuilder) WindowTumbling(size time.Duration) *SelectBuilder {
+	sz, err := duration(size)
+	if err != nil {
+		return s.fail(err)
+	}
+	s.window = fmt.Sprintf("TUMBLING (SIZE %s)", sz)
+	return s
+}
+
+// WindowHopping groups rows into fixed size windows which start every advance and may overlap
+func (s *SelectBuilder) WindowHopping(size, advance time.Duration) *SelectBuilder {
+	sz, err := duration(size)
+	if err != nil {
+		return s.fail(err)
+	}
+	adv, err := duration(advance)
+	if err != nil {
+		return s.fail(err)
+	}
+	s.window = fmt.Sprintf("HOPPING (SIZE %s, ADVANCE BY %s)", sz, adv)
+	return s
+}
+
+// WindowSession groups rows into sessions which end after a period of inactivity
+func (s *SelectBuilder) WindowSession(gap time.Duration) *SelectBuilder {
+	g, err := duration(gap)
+	if err != nil {
+		return s.fail(err)
+	}
+	s.window = fmt.Sprintf("SESSION (%s)", g)
+	return s
+}
+
+// Where filters the rows with a condition, in which ? placeholders are replaced with the literals of args. Conditions from several calls are combined with AND.
+func (s *SelectBuilder) Where(cond string, args ...interface{}) *SelectBuilder {
+	c, err := bind(cond, args)
+	if err != nil {
+		return s.fail(err)
+	}
+	s.where = append(s.where, c)
+	return s
+}
+
+// GroupBy groups the rows by the named columns, see Identifier
+func (s *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	for _, col := range columns {
+		s.groupBy = append(s.groupBy, Identifier(col))
+	}
+	return s
+}
+
+// Having filters the groups with a condition, see Where
+func (s *SelectBuilder) Having(cond string, args ...interface{}) *SelectBuilder {
+	c, err := bind(cond, args)
+	if err != nil {
+		return s.fail(err)
+	}
+	s.having = append(s.having, c)
+	return s
+}
+
+// EmitChanges makes the query a push query, which streams changes until it is closed
+func (s *SelectBuilder) EmitChanges() *SelectBuilder {
+	s.emit = true
+	return s
+}
+
+// Limit stops the query after n rows
+func (s *SelectBuilder) Limit(n int) *SelectBuilder {
+	if n < 0 {
+		return s.fail(fmt.Errorf("%w: negative limit %d", ErrInvalidStatement, n))
+	}
+	s.limit = n
+	return s
+}
+
+// IsPush reports whether the query is a push query
+func (s *SelectBuilder) IsPush() bool {
+	return s.emit
+}
+
+// ToSQL renders the query, including a trailing semicolon
+func (s *SelectBuilder) ToSQL() (string, error) {
+	q, err := s.render()
+	if err != nil {
+		return "", err
+	}
+	return q + ";", nil
+}
+
+// QueryPayload renders the query as a payload for Client.Query
+func (s *SelectBuilder) QueryPayload() (ksql.QueryPayload, error) {
+	q, err := s.ToSQL()
+	return ksql.QueryPayload{KSQL: q}, err
+}
+
+// QueryStreamPayload renders the query as a payload for Client.QueryStream
+func (s *SelectBuilder) QueryStreamPayload() (ksql.QueryStreamPayload, error) {
+	q, err := s.ToSQL()
+	return ksql.QueryStreamPayload{KSQL: q}, err
+}
+
+// render renders the query without a trailing semicolon, so that it can be embedded in other statements
+func (s *SelectBuilder) render() (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	switch {
+	case len(s.columns) == 0:
+		return "", fmt.Errorf("%w: a query must select at least one column", ErrInvalidStatement)
+	case s.from == "":
+		return "", fmt.Errorf("%w: a query must have a FROM clause", ErrInvalidStatement)
+	case len(s.having) > 0 && len(s.groupBy) == 0:
+		return "", fmt.Errorf("%w: HAVING requires GROUP BY", ErrInvalidStatement)
+	case s.window != "" && len(s.groupBy) == 0:
+		return "", fmt.Errorf("%w: WINDOW requires GROUP BY", ErrInvalidStatement)
+	}
+	var b strings.Builder
+	b.WriteString("SELECT ")
+	b.WriteString(strings.Join(s.columns, ", "))
+	b.WriteString(" FROM ")
+	b.WriteString(s.from)
+	for _, j := range s.joins {
+		b.WriteString(" ")
+		b.WriteString(j)
+	}
+	if s.window != "" {
+		b.WriteString(" WINDOW ")
+		b.WriteString(s.window)
+	}
+	if len(s.where) > 0 {
+		b.WriteString(" WHERE ")
+		b.WriteString(conjunction(s.where))
+	}
+	if len(s.groupBy) > 0 {
+		b.WriteString(" GROUP BY ")
+		b.WriteString(strings.Join(s.groupBy, ", "))
+	}
+	if len(s.having) > 0 {
+		b.WriteString(" HAVING ")
+		b.WriteString(conjunction(s.having))
+	}
+	if s.emit {
+		b.WriteString(" EMIT CHANGES")
+	}
+	if s.limit > 0 {
+		fmt.Fprintf(&b, " LIMIT %d", s.limit)
+	}
+	return b.String(), nil
+}
+
+// fail records the first error
+func (s *SelectBuilder) fail(err error) *SelectBuilder {
+	if s.err == nil {
+		s.err = err
+	}
+	return s
+}
+
+// conjunction combines conditions with AND, parenthesising them when there is more than one
+func conjunction(conds []string) string {
+	if len(conds) == 1 {
+		return conds[0]
+	}
+	return "(" + strings.Join(conds, ") AND (") + ")"
+}
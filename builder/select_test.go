@@ -0,0 +1,101 @@
+package builder
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	ksql "github.com/vancelongwill/ksql-go/client"
+	"github.com/vancelongwill/ksql-go/lexer"
+)
+
+func TestSelect(t *testing.T) {
+	t.Run("it should render a pull query with bound arguments", func(t *testing.T) {
+		got, err := Select("k", "v1").From("t1").Where("k = ?", "it's").Limit(10).ToSQL()
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT k, v1 FROM t1 WHERE k = 'it''s' LIMIT 10;", got)
+	})
+
+	t.Run("it should parenthesise negative arguments so they can't start a comment", func(t *testing.T) {
+		got, err := Select("k").From("t1").Where("v = 0-?", -5).ToSQL()
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT k FROM t1 WHERE v = 0-(-5);", got)
+	})
+	t.Run("it should render a windowed push query", func(t *testing.T) {
+		got, err := Select("region").
+			Column("COUNT(*)", "total").
+			From("orders").
+			WindowTumbling(time.Hour).
+			Where("amount > ?", 10).
+			Where("status IN (?, ?)", "new", "paid").
+			GroupBy("region").
+			Having("COUNT(*) > ?", 1).
+			EmitChanges().
+			ToSQL()
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT region, COUNT(*) AS total FROM orders WINDOW TUMBLING (SIZE 1 HOURS) WHERE (amount > 10) AND (status IN ('new', 'paid')) GROUP BY region HAVING COUNT(*) > 1 EMIT CHANGES;", got)
+		kind, err := lexer.Classify(got)
+		assert.NoError(t, err)
+		assert.Equal(t, lexer.PushQuery, kind)
+	})
+
+	t.Run("it should render hopping and session windows", func(t *testing.T) {
+		got, err := Select("k").Column("COUNT(*)", "").From("s1").WindowHopping(90*time.Minute, 30*time.Second).GroupBy("k").EmitChanges().ToSQL()
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT k, COUNT(*) FROM s1 WINDOW HOPPING (SIZE 90 MINUTES, ADVANCE BY 30 SECONDS) GROUP BY k EMIT CHANGES;", got)
+
+		got, err = Select("k").Column("COUNT(*)", "").From("s1").WindowSession(1500 * time.Millisecond).GroupBy("k").EmitChanges().ToSQL()
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT k, COUNT(*) FROM s1 WINDOW SESSION (1500 MILLISECONDS) GROUP BY k EMIT CHANGES;", got)
+	})
+
+	t.Run("it should render joins", func(t *testing.T) {
+		got, err := Select("orders.id", "customers.name").
+			From("orders").
+			LeftJoin("customers", "orders.customer_id = customers.id").
+			EmitChanges().
+			ToSQL()
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT orders.id, customers.name FROM orders LEFT JOIN customers ON orders.customer_id = customers.id EMIT CHANGES;", got)
+	})
+
+	t.Run("it should quote identifiers which aren't plain names", func(t *testing.T) {
+		got, err := Select("*", "from", "Mixed Case").From("my-stream").ToSQL()
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT *, `from`, `Mixed Case` FROM `my-stream`;", got)
+	})
+
+	t.Run("it should build query payloads", func(t *testing.T) {
+		q := Select("*").From("t1").Where("k = ?", 1)
+		payload, err := q.QueryPayload()
+		assert.NoError(t, err)
+		assert.Equal(t, ksql.QueryPayload{KSQL: "SELECT * FROM t1 WHERE k = 1;"}, payload)
+		streamPayload, err := q.EmitChanges().QueryStreamPayload()
+		assert.NoError(t, err)
+		assert.Equal(t, ksql.QueryStreamPayload{KSQL: "SELECT * FROM t1 WHERE k = 1 EMIT CHANGES;"}, streamPayload)
+		assert.True(t, q.IsPush())
+	})
+
+	t.Run("it should report invalid queries", func(t *testing.T) {
+		for name, q := range map[string]*SelectBuilder{
+			"missing FROM":            Select("k"),
+			"missing columns":         Select().From("t1"),
+			"too few arguments":       Select("k").From("t1").Where("k = ? AND v = ?", 1),
+			"too many arguments":      Select("k").From("t1").Where("k = ?", 1, 2),
+			"HAVING without GROUP BY": Select("k").From("t1").Having("COUNT(*) > 1"),
+			"WINDOW without GROUP BY": Select("k").From("t1").WindowTumbling(time.Second),
+			"fractional window":       Select("k").From("t1").WindowTumbling(time.Microsecond).GroupBy("k"),
+			"negative limit":          Select("k").From("t1").Limit(-1),
+		} {
+			_, err := q.ToSQL()
+			assert.True(t, errors.Is(err, ErrInvalidStatement) || errors.Is(err, ErrArgumentCount), "%s: %v", name, err)
+		}
+	})
+
+	t.Run("it should leave placeholders in string literals alone", func(t *testing.T) {
+		got, err := Select("k").From("t1").Where("v = '?' AND k = ?", "a").ToSQL()
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT k FROM t1 WHERE v = '?' AND k = 'a';", got)
+	})
+}
@@ -0,0 +1,89 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vancelongwill/ksql-go/internal/tags"
+	"github.com/vancelongwill/ksql-go/ksqltypes"
+	"github.com/vancelongwill/ksql-go/lexer"
+)
+
+var (
+	// ErrArgumentCount is returned when the number of ? placeholders in an expression doesn't match the number of arguments
+	ErrArgumentCount = errors.New("wrong number of arguments")
+	// ErrInvalidStatement is returned when a builder is missing a required clause, or has clauses which can't be combined
+	ErrInvalidStatement = errors.New("invalid statement")
+)
+
+// Identifier renders a column or source name, quoting it only if it isn't a plain name
+func Identifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if part == "*" && i == len(parts)-1 {
+			continue
+		}
+		if !tags.Unquoted(part) {
+			parts[i] = ksqltypes.QuoteIdentifier(part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// bind replaces each ? placeholder in expr with the literal of the matching argument
+func bind(expr string, args []interface{}) (string, error) {
+	tokens, err := lexer.Tokenize(expr)
+	if err != nil {
+		return "", err
+	}
+	var (
+		b    strings.Builder
+		next int
+	)
+	for _, tok := range tokens {
+		if tok.Kind != lexer.Placeholder || tok.Text != "?" {
+			b.WriteString(tok.Text)
+			continue
+		}
+		if next >= len(args) {
+			return "", fmt.Errorf("%w: %q has more placeholders than the %d arguments given", ErrArgumentCount, expr, len(args))
+		}
+		literal, err := ksqltypes.Literal(args[next])
+		if err != nil {
+			return "", fmt.Errorf("argument %d of %q: %w", next+1, expr, err)
+		}
+		b.WriteString(literal)
+		next++
+	}
+	if next != len(args) {
+		return "", fmt.Errorf("%w: %q has %d placeholders but %d arguments were given", ErrArgumentCount, expr, next, len(args))
+	}
+	return b.String(), nil
+}
+
+// durationUnits are the time units of window sizes, largest first
+var durationUnits = []struct {
+	unit string
+	d    time.Duration
+}{
+	{"DAYS", 24 * time.Hour},
+	{"HOURS", time.Hour},
+	{"MINUTES", time.Minute},
+	{"SECONDS", time.Second},
+	{"MILLISECONDS", time.Millisecond},
+}
+
+// duration renders a duration in the largest unit which divides it exactly, e.g. 90 MINUTES
+func duration(d time.Duration) (string, error) {
+	if d <= 0 {
+		return "", fmt.Errorf("%w: duration %s must be positive", ErrInvalidStatement, d)
+	}
+	for _, u := range durationUnits {
+		if d%u.d == 0 {
+			return fmt.Sprintf("%d %s", d/u.d, u.unit), nil
+		}
+	}
+	return "", fmt.Errorf("%w: duration %s must be a whole number of milliseconds", ErrInvalidStatement, d)
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff configures an exponential backoff between reconnection attempts
+type Backoff struct {
+	// Min is the delay before the first attempt
+	Min time.Duration
+	// Max caps the delay between attempts, including any jitter
+	Max time.Duration
+	// Factor is the multiplier applied to the delay after each attempt
+	Factor float64
+	// Jitter randomises each delay by up to +/-50% to avoid reconnecting clients stampeding the server
+	Jitter bool
+}
+
+// DefaultBackoff starts at 100ms and doubles up to a maximum of 30s
+var DefaultBackoff = Backoff{
+	Min:    100 * time.Millisecond,
+	Max:    30 * time.Second,
+	Factor: 2,
+	Jitter: true,
+}
+
+// Duration returns the delay before the given attempt, starting at 1
+func (b Backoff) Duration(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	factor := b.Factor
+	if factor < 1 {
+		factor = 1
+	}
+	d := float64(b.Min) * math.Pow(factor, float64(attempt-1))
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	if b.Jitter {
+		d = d/2 + rand.Float64()*d
+		// capping again keeps the jittered delay within Max, while still spreading delays at the cap
+		if b.Max > 0 && d > float64(b.Max) {
+			d = float64(b.Max)
+		}
+	}
+	return time.Duration(d)
+}
+
+// wait sleeps for the duration of the given attempt, or until the context is done
+func (b Backoff) wait(ctx context.Context, attempt int) error {
+	t := time.NewTimer(b.Duration(attempt))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -6,16 +6,28 @@ import (
 
 	"net"
 	"net/http"
+	"sync"
+	"time"
 
 	"golang.org/x/net/http2"
 )
 
 // ksqldb is a ksqlDB client
 type ksqldb struct {
-	http                 *http.Client
-	baseURL              string
-	rows                 []*QueryStreamRows
-	insertsStreamWriters []*InsertsStreamWriter
+	http        *http.Client
+	baseURL     string
+	consistency *ConsistencyVector
+	username    string
+	password    string
+	tlsConfig   *tls.Config
+	dialTimeout time.Duration
+	failover    []string
+	useNumber   bool
+
+	// mu guards the query streams and inserts streams which are still open, so that Close can close them
+	mu                   sync.Mutex
+	rows                 map[*QueryStreamRows]struct{}
+	insertsStreamWriters map[*InsertsStreamWriter]struct{}
 }
 
 // Client is a ksqlDB REST API client
@@ -26,6 +38,8 @@ type Client interface {
 	Describe(ctx context.Context, source string) (DescribeResult, error)
 	// Exec runs KSQL statements which can be anything except SELECT
 	Exec(ctx context.Context, params ExecPayload) ([]ExecResult, error)
+	// ExecScript splits a script into its statements and executes them, returning a result for each statement
+	ExecScript(ctx context.Context, script string, options ...ExecScriptOption) ([]StatementResult, error)
 	// Explain returns details of the execution plan for a query or expression
 	Explain(ctx context.Context, queryNameOrExpression string) (ExplainResult, error)
 	// Healthcheck gets basic health information from the ksqlDB cluster
@@ -33,7 +47,9 @@ type Client interface {
 	// Info returns status information about the ksqlDB cluster
 	Info(ctx context.Context) (InfoResult, error)
 	// InsertsStream allows you to insert rows into an existing ksqlDB stream. The stream must have already been created in ksqlDB.
-	InsertsStream(ctx context.Context, payload InsertsStreamTargetPayload) (*InsertsStreamWriter, error)
+	InsertsStream(ctx context.Context, payload InsertsStreamTargetPayload, options ...InsertsStreamOption) (*InsertsStreamWriter, error)
+	// ResilientInsertsStream opens an inserts stream which automatically reconnects and replays unacknowledged rows when the connection drops
+	ResilientInsertsStream(ctx context.Context, payload InsertsStreamTargetPayload, policy ReconnectPolicy, options ...InsertsStreamOption) (*ResilientInsertsStreamWriter, error)
 	// ListQueries is a convenience method which executes a `LIST QUERIES;` operation
 	ListQueries(ctx context.Context) (ListQueriesResult, error)
 	// ListTables is a convenience method which executes a `LIST TABLES;` operation
@@ -46,18 +62,35 @@ type Client interface {
 	Query(ctx context.Context, payload QueryPayload) (*QueryRows, error)
 	// QueryStream runs a streaming push & pull query
 	QueryStream(ctx context.Context, payload QueryStreamPayload) (*QueryStreamRows, error)
+	// ResilientQueryStream runs a push query which automatically reconnects and resumes from the latest continuation token when the connection drops
+	ResilientQueryStream(ctx context.Context, payload QueryStreamPayload, policy ReconnectPolicy) (*ResilientQueryStreamRows, error)
+	// Subscribe runs a streaming push & pull query and delivers the rows over a channel
+	Subscribe(ctx context.Context, payload QueryStreamPayload, options ...SubscribeOption) (*Subscription, error)
 	// CloseQuery explicitly terminates a push query stream
 	CloseQuery(ctx context.Context, payload CloseQueryPayload) error
 	// TerminateCluster terminates a running ksqlDB cluster
 	TerminateCluster(ctx context.Context, payload TerminateClusterPayload) error
 }
 
-func createInsecureHTTP2Client() *http.Client {
+func createInsecureHTTP2Client(dialTimeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: dialTimeout}
 	return &http.Client{
 		Transport: &http2.Transport{
 			AllowHTTP: true,
 			DialTLS: func(network string, addr string, cfg *tls.Config) (net.Conn, error) {
-				return net.Dial(network, addr)
+				return dialer.Dial(network, addr)
+			},
+		},
+	}
+}
+
+func createHTTP2Client(config *tls.Config, dialTimeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	return &http.Client{
+		Transport: &http2.Transport{
+			TLSClientConfig: config,
+			DialTLS: func(network string, addr string, cfg *tls.Config) (net.Conn, error) {
+				return tls.DialWithDialer(dialer, network, addr, cfg)
 			},
 		},
 	}
@@ -65,14 +98,25 @@ func createInsecureHTTP2Client() *http.Client {
 
 // New constructs a new ksqlDB REST API client.
 //
-// By default this uses an insecure HTTP2 client. In production you should pass in your own client via the WithHTTPClient option.
+// By default this uses an insecure HTTP2 client. In production you should configure TLS with the WithTLSConfig option, or pass in your own client via the WithHTTPClient option.
 func New(baseURL string, options ...Option) Client {
 	client := &ksqldb{
-		baseURL: baseURL,
-		http:    createInsecureHTTP2Client(),
+		baseURL:              baseURL,
+		rows:                 make(map[*QueryStreamRows]struct{}),
+		insertsStreamWriters: make(map[*InsertsStreamWriter]struct{}),
 	}
 	for _, opt := range options {
 		opt(client)
 	}
+	if client.http == nil {
+		if client.tlsConfig != nil {
+			client.http = createHTTP2Client(client.tlsConfig, client.dialTimeout)
+		} else {
+			client.http = createInsecureHTTP2Client(client.dialTimeout)
+		}
+	}
+	if client.username != "" || client.password != "" || len(client.failover) > 0 {
+		client.http = client.wrapTransport(client.http)
+	}
 	return client
 }
@@ -2,15 +2,51 @@ package client
 
 // Close gracefully closes all open connections in order to reuse TCP connections via keep-alive
 func (c *ksqldb) Close() error {
-	for _, rows := range c.rows {
-		if err := rows.Close(); err != nil {
-			return err
+	c.mu.Lock()
+	rows := make([]*QueryStreamRows, 0, len(c.rows))
+	for r := range c.rows {
+		rows = append(rows, r)
+	}
+	writers := make([]*InsertsStreamWriter, 0, len(c.insertsStreamWriters))
+	for w := range c.insertsStreamWriters {
+		writers = append(writers, w)
+	}
+	c.mu.Unlock()
+	// closing removes each of them from the client, so the lock isn't held
+	var firstErr error
+	for _, r := range rows {
+		if err := r.Close(); err != nil && firstErr == nil {
+			firstErr = err
 		}
 	}
-	for _, wtr := range c.insertsStreamWriters {
-		if err := wtr.Close(); err != nil {
-			return err
+	for _, w := range writers {
+		if err := w.Close(); err != nil && firstErr == nil {
+			firstErr = err
 		}
 	}
-	return nil
+	return firstErr
+}
+
+// trackRows records query stream rows until they are closed
+func (c *ksqldb) trackRows(r *QueryStreamRows) {
+	r.release = func() {
+		c.mu.Lock()
+		delete(c.rows, r)
+		c.mu.Unlock()
+	}
+	c.mu.Lock()
+	c.rows[r] = struct{}{}
+	c.mu.Unlock()
+}
+
+// trackWriter records an inserts stream until it is closed
+func (c *ksqldb) trackWriter(w *InsertsStreamWriter) {
+	w.release = func() {
+		c.mu.Lock()
+		delete(c.insertsStreamWriters, w)
+		c.mu.Unlock()
+	}
+	c.mu.Lock()
+	c.insertsStreamWriters[w] = struct{}{}
+	c.mu.Unlock()
 }
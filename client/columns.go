@@ -5,6 +5,7 @@ const unset int = -1
 type columns struct {
 	count int
 	names []string
+	types []string
 }
 
 func (c columns) Validate(dest []interface{}) error {
@@ -26,3 +27,12 @@ func (c columns) Columns() []string {
 	cols := make([]string, c.count)
 	return cols
 }
+
+// ColumnTypes returns the ksqlDB types of the columns (e.g. 'BIGINT', 'STRING', 'ARRAY<STRING>'), or empty strings if they're unknown
+func (c columns) ColumnTypes() []string {
+	if len(c.types) > 0 &&
+		(c.count == unset || len(c.types) == c.count) {
+		return c.types
+	}
+	return make([]string, len(c.Columns()))
+}
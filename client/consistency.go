@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	// consistencyTokenEnabledProperty instructs the server to emit a consistency token at the end of pull query results
+	consistencyTokenEnabledProperty = "ksql.query.pull.consistency.token.enabled"
+	// consistencyTokenRequestProperty is the request property used to send the latest consistency token with a pull query
+	consistencyTokenRequestProperty = "request.ksql.query.pull.consistency.offset.vector"
+)
+
+// ConsistencyVector holds the latest consistency token received from ksqlDB.
+//
+// When a ConsistencyVector is attached to a client or a context, each pull query run with QueryStream sends the current token and records the token returned by the server,
+// ensuring that reads are at least as fresh as any previous read (monotonic reads). It is safe for concurrent use.
+type ConsistencyVector struct {
+	mu    sync.RWMutex
+	token string
+}
+
+// NewConsistencyVector creates a ConsistencyVector seeded with an optional token, e.g. one persisted from a previous session
+func NewConsistencyVector(token string) *ConsistencyVector {
+	return &ConsistencyVector{token: token}
+}
+
+// Token returns the latest consistency token, or an empty string if no token has been received yet
+func (v *ConsistencyVector) Token() string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.token
+}
+
+// Seed explicitly sets the consistency token sent with subsequent pull queries
+func (v *ConsistencyVector) Seed(token string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.token = token
+}
+
+type consistencyVectorKey struct{}
+
+// WithSessionConsistency returns a copy of ctx which carries its own ConsistencyVector.
+//
+// Queries run with the returned context use this vector instead of the client-wide vector, allowing monotonic reads to be scoped to a session.
+func WithSessionConsistency(ctx context.Context, v *ConsistencyVector) context.Context {
+	return context.WithValue(ctx, consistencyVectorKey{}, v)
+}
+
+// consistencyVectorFor returns the session vector from the context if present, falling back to the client-wide vector
+func (c *ksqldb) consistencyVectorFor(ctx context.Context) *ConsistencyVector {
+	if v, ok := ctx.Value(consistencyVectorKey{}).(*ConsistencyVector); ok && v != nil {
+		return v
+	}
+	return c.consistency
+}
+
+// withConsistency adds the consistency token properties to the payload
+func withConsistency(payload QueryStreamPayload, v *ConsistencyVector) QueryStreamPayload {
+	props := make(map[string]string, len(payload.Properties)+1)
+	for k, val := range payload.Properties {
+		props[k] = val
+	}
+	props[consistencyTokenEnabledProperty] = "true"
+	payload.Properties = props
+	if token := v.Token(); token != "" {
+		reqProps := make(map[string]interface{}, len(payload.RequestProperties)+1)
+		for k, val := range payload.RequestProperties {
+			reqProps[k] = val
+		}
+		reqProps[consistencyTokenRequestProperty] = token
+		payload.RequestProperties = reqProps
+	}
+	return payload
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vancelongwill/ksql-go/client/internal/testutils"
+)
+
+func TestConsistencyVector(t *testing.T) {
+	payload := QueryStreamPayload{
+		KSQL: "SELECT * FROM t1 WHERE k = 'k1';",
+	}
+	header := QueryResultHeader{
+		ColumnNames: []string{"K"},
+		ColumnTypes: []string{"STRING"},
+	}
+	// the server responds with the received token suffixed with "+1"
+	handler := func(t *testing.T, wantToken interface{}) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			var got QueryStreamPayload
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+			assert.Equal(t, "true", got.Properties[consistencyTokenEnabledProperty])
+			assert.Equal(t, wantToken, got.RequestProperties[consistencyTokenRequestProperty])
+			token, _ := got.RequestProperties[consistencyTokenRequestProperty].(string)
+			enc := json.NewEncoder(w)
+			assert.NoError(t, enc.Encode(&header))
+			assert.NoError(t, enc.Encode([]interface{}{"k1"}))
+			assert.NoError(t, enc.Encode(map[string]string{"consistencyToken": token + "+1"}))
+		}
+	}
+	drain := func(t *testing.T, rows *QueryStreamRows) {
+		dest := make([]interface{}, 1)
+		assert.NoError(t, rows.Next(dest))
+		assert.Equal(t, io.EOF, rows.Next(dest))
+	}
+
+	t.Run("given a client-wide vector", func(t *testing.T) {
+		t.Run("it should record the token returned by the server", func(t *testing.T) {
+			srv := testutils.Server(queryStreamPath, handler(t, nil))
+			srv.StartTLS()
+			defer srv.Close()
+			v := NewConsistencyVector("")
+			c := New(srv.URL, WithHTTPClient(testutils.Client()), WithConsistencyVector(v))
+			rows, err := c.QueryStream(context.Background(), payload)
+			assert.NoError(t, err)
+			drain(t, rows)
+			assert.Equal(t, "+1", v.Token())
+		})
+		t.Run("it should send the seeded token", func(t *testing.T) {
+			srv := testutils.Server(queryStreamPath, handler(t, "seed"))
+			srv.StartTLS()
+			defer srv.Close()
+			v := NewConsistencyVector("")
+			v.Seed("seed")
+			c := New(srv.URL, WithHTTPClient(testutils.Client()), WithConsistencyVector(v))
+			rows, err := c.QueryStream(context.Background(), payload)
+			assert.NoError(t, err)
+			drain(t, rows)
+			assert.Equal(t, "seed+1", v.Token())
+		})
+	})
+
+	t.Run("given a session vector", func(t *testing.T) {
+		srv := testutils.Server(queryStreamPath, handler(t, "session"))
+		srv.StartTLS()
+		defer srv.Close()
+		clientVector := NewConsistencyVector("client")
+		sessionVector := NewConsistencyVector("session")
+		c := New(srv.URL, WithHTTPClient(testutils.Client()), WithConsistencyVector(clientVector))
+		ctx := WithSessionConsistency(context.Background(), sessionVector)
+		rows, err := c.QueryStream(ctx, payload)
+		assert.NoError(t, err)
+		drain(t, rows)
+		assert.Equal(t, "session+1", sessionVector.Token())
+		assert.Equal(t, "client", clientVector.Token(), "the client-wide vector should be untouched")
+	})
+
+	t.Run("given a push query", func(t *testing.T) {
+		t.Run("it should not send the consistency properties", func(t *testing.T) {
+			srv := testutils.Server(queryStreamPath, func(w http.ResponseWriter, r *http.Request) {
+				var got QueryStreamPayload
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+				assert.NotContains(t, got.Properties, consistencyTokenEnabledProperty)
+				assert.NotContains(t, got.RequestProperties, consistencyTokenRequestProperty)
+				assert.NoError(t, json.NewEncoder(w).Encode(&QueryResultHeader{QueryID: "someid", ColumnNames: []string{"K"}, ColumnTypes: []string{"STRING"}}))
+			})
+			srv.StartTLS()
+			defer srv.Close()
+			v := NewConsistencyVector("seed")
+			c := New(srv.URL, WithHTTPClient(testutils.Client()), WithConsistencyVector(v))
+			rows, err := c.QueryStream(context.Background(), QueryStreamPayload{KSQL: "SELECT * FROM t1 EMIT CHANGES;"})
+			assert.NoError(t, err)
+			assert.Equal(t, io.EOF, rows.Next(make([]interface{}, 1)))
+			assert.Equal(t, "seed", v.Token(), "the token should be untouched")
+		})
+	})
+
+	t.Run("when no token has been received yet", func(t *testing.T) {
+		payload := QueryStreamPayload{KSQL: "SELECT * FROM t1 WHERE k = 'k1';"}
+		got := withConsistency(payload, NewConsistencyVector(""))
+		assert.Nil(t, got.RequestProperties, "no token should be sent before one has been received")
+		assert.Nil(t, payload.Properties, "the original payload should not be modified")
+	})
+}
@@ -30,3 +30,17 @@ func TestDescribeExecAs(t *testing.T) {
 		assert.False(t, res.As(&describe))
 	})
 }
+
+func TestSchemaMember(t *testing.T) {
+	t.Run("it should return the schema of the elements of an ARRAY", func(t *testing.T) {
+		s := Schema{Type: "ARRAY", MemberSchema: map[string]interface{}{
+			"type":         "MAP",
+			"memberSchema": map[string]interface{}{"type": "DECIMAL"},
+		}}
+		assert.Equal(t, Schema{Type: "MAP", MemberSchema: map[string]interface{}{"type": "DECIMAL"}}, s.Member())
+		assert.Equal(t, Schema{Type: "DECIMAL"}, s.Member().Member())
+	})
+	t.Run("it should return the zero Schema for other types", func(t *testing.T) {
+		assert.Equal(t, Schema{}, Schema{Type: "INTEGER"}.Member())
+	})
+}
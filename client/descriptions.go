@@ -1,5 +1,7 @@
 package client
 
+import "encoding/json"
+
 // Warning represents a non-fatal user warning
 type Warning struct {
 	Message string `json:"message"`
@@ -35,12 +37,29 @@ type Schema struct {
 	Fields []Field `json:"fields,omitempty"`
 }
 
+// Member returns the schema of the elements of an ARRAY or the values of a MAP, or the zero Schema for other types
+func (s Schema) Member() Schema {
+	var member Schema
+	if s.MemberSchema == nil {
+		return member
+	}
+	// MemberSchema is untyped, so it is converted via JSON
+	b, err := json.Marshal(s.MemberSchema)
+	if err != nil {
+		return member
+	}
+	_ = json.Unmarshal(b, &member)
+	return member
+}
+
 // Field represents a single fields in ksqlDB
 type Field struct {
 	// The name of the field.
 	Name string `json:"name"`
 	// A schema object that describes the schema of the field.
 	Schema Schema `json:"schema"`
+	// Type is KEY for key columns, and empty for value columns
+	Type string `json:"type,omitempty"`
 }
 
 // SourceDescription is a detailed description of the source (a STREAM or TABLE)
@@ -28,6 +28,8 @@ type ExecPayload struct {
 	StreamsProperties StreamsProperties `json:"streamsProperties,omitempty"`
 	// CommandSequenceNumber optionally waits until the specified sequence has been completed before running
 	CommandSequenceNumber int64 `json:"commandSequenceNumber,omitempty"`
+	// SessionVariables optionally defines variables which are substituted into the statements
+	SessionVariables map[string]interface{} `json:"sessionVariables,omitempty"`
 }
 
 // ExecResult is the response result from the /ksql endpoint
@@ -71,24 +73,38 @@ func (e ExecResult) As(target Result) bool {
 
 // Exec runs KSQL statements which can be anything except `SELECT`, which is not supported by the ksqlDB REST API.
 func (c *ksqldb) Exec(ctx context.Context, payload ExecPayload) ([]ExecResult, error) {
+	by, _, err := c.exec(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	return decodeExecResults(by)
+}
+
+// exec posts the payload to the /ksql endpoint, returning the response body and status code
+func (c *ksqldb) exec(ctx context.Context, payload ExecPayload) ([]byte, int, error) {
 	b := &bytes.Buffer{}
 	err := json.NewEncoder(b).Encode(&payload)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	req, err := makeRequest(ctx, c.baseURL, execPath, http.MethodPost, b)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("unable to make Exec request: %w", err)
+		return nil, 0, fmt.Errorf("unable to make Exec request: %w", err)
 	}
-	var results []ExecResult
+	defer resp.Body.Close()
 	by, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("unable to read response body: %w", err)
+		return nil, 0, fmt.Errorf("unable to read response body: %w", err)
 	}
+	return by, resp.StatusCode, nil
+}
+
+func decodeExecResults(by []byte) ([]ExecResult, error) {
+	var results []ExecResult
 	if err := json.Unmarshal(by, &results); err != nil {
 		var result ExecResult
 		if err := json.Unmarshal(by, &result); err != nil {
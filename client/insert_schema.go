@@ -0,0 +1,347 @@
+package client
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRow is returned when a row doesn't match the schema of the stream it is being inserted into
+var ErrInvalidRow = errors.New("row does not match the target schema")
+
+// keyFieldType is the field type used by DESCRIBE for key columns
+const keyFieldType = "KEY"
+
+var (
+	valuerType        = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	numberType        = reflect.TypeOf(json.Number(""))
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// insertSchema converts rows into the wire representation of the columns of an inserts stream target
+type insertSchema struct {
+	columns []insertColumn
+	byName  map[string]int
+}
+
+type insertColumn struct {
+	name   string
+	key    bool
+	schema Schema
+}
+
+// describeInsertSchema fetches the schema of the target stream via DESCRIBE
+func (c *ksqldb) describeInsertSchema(ctx context.Context, target string) (*insertSchema, error) {
+	describe, err := c.Describe(ctx, target)
+	if err != nil {
+		return nil, fmt.Errorf("unable to describe %s: %w", target, err)
+	}
+	if len(describe.SourceDescription.Fields) == 0 {
+		return nil, fmt.Errorf("unable to describe %s: no columns found", target)
+	}
+	return newInsertSchema(describe.SourceDescription), nil
+}
+
+func newInsertSchema(desc SourceDescription) *insertSchema {
+	s := &insertSchema{byName: make(map[string]int, len(desc.Fields))}
+	for _, f := range desc.Fields {
+		s.byName[strings.ToUpper(f.Name)] = len(s.columns)
+		s.columns = append(s.columns, insertColumn{
+			name: f.Name,
+			// older versions of ksqlDB only report the name of the key column
+			key:    f.Type == keyFieldType || (desc.Key != "" && strings.EqualFold(f.Name, desc.Key)),
+			schema: f.Schema,
+		})
+	}
+	return s
+}
+
+// encode converts a struct, or a map keyed by column name, into a row keyed by the column names of the target.
+//
+// Struct fields are matched case-insensitively by their `ksql` tags, in the same way as ScanStruct.
+func (s *insertSchema) encode(p interface{}) (map[string]interface{}, error) {
+	values, err := rowValues(reflect.ValueOf(p))
+	if err != nil {
+		return nil, err
+	}
+	row := make(map[string]interface{}, len(values))
+	for name, v := range values {
+		i, ok := s.byName[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown column %s", ErrInvalidRow, name)
+		}
+		col := s.columns[i]
+		out, err := encodeValue(v, col.schema)
+		if err != nil {
+			return nil, fmt.Errorf("%w: column %s: %v", ErrInvalidRow, col.name, err)
+		}
+		row[col.name] = out
+	}
+	for _, col := range s.columns {
+		if col.key && row[col.name] == nil {
+			return nil, fmt.Errorf("%w: missing key column %s", ErrInvalidRow, col.name)
+		}
+	}
+	return row, nil
+}
+
+// rowValues returns the values of a struct or map keyed by their upper-cased names
+func rowValues(v reflect.Value) (map[string]reflect.Value, error) {
+	v = indirect(v)
+	switch v.Kind() {
+	case reflect.Struct:
+		fields, err := structFields(v.Type())
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRow, err)
+		}
+		values := make(map[string]reflect.Value, len(fields))
+		for name, f := range fields {
+			fv, ok := fieldByIndexNoAlloc(v, f.Index)
+			if ok {
+				values[name] = fv
+			}
+		}
+		return values, nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		values := make(map[string]reflect.Value, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			values[strings.ToUpper(iter.Key().String())] = iter.Value()
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("%w: expected a struct or a map with string keys but got %s", ErrInvalidRow, v.Kind())
+}
+
+// fieldByIndexNoAlloc is like reflect.Value.FieldByIndex, except that it reports false rather than panicking when an embedded struct pointer is nil
+func fieldByIndexNoAlloc(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// indirect dereferences pointers and interfaces, returning an invalid value if any are nil
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// encodeValue converts v into the JSON representation expected by ksqlDB for the given schema
+func encodeValue(v reflect.Value, schema Schema) (interface{}, error) {
+	if v.IsValid() && v.Type().Implements(valuerType) {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil, nil
+		}
+		value, err := v.Interface().(driver.Valuer).Value()
+		if err != nil {
+			return nil, err
+		}
+		v = reflect.ValueOf(value)
+	}
+	v = indirect(v)
+	if !v.IsValid() {
+		return nil, nil
+	}
+	switch strings.ToUpper(schema.Type) {
+	case "BOOLEAN":
+		if v.Kind() == reflect.Bool {
+			return v.Bool(), nil
+		}
+	case "INTEGER":
+		if n, ok := integerValue(v); ok {
+			if n < math.MinInt32 || n > math.MaxInt32 {
+				return nil, fmt.Errorf("value %d overflows INTEGER", n)
+			}
+			return n, nil
+		}
+	case "BIGINT":
+		if n, ok := integerValue(v); ok {
+			return n, nil
+		}
+	case "DOUBLE":
+		if n, ok := integerValue(v); ok {
+			return float64(n), nil
+		}
+		if v.Kind() == reflect.Float32 || v.Kind() == reflect.Float64 {
+			return v.Float(), nil
+		}
+		if v.Type() == numberType {
+			return v.Interface().(json.Number).Float64()
+		}
+	case "DECIMAL":
+		return encodeDecimal(v)
+	case "STRING":
+		if v.Kind() == reflect.String {
+			return v.String(), nil
+		}
+		if s, ok, err := marshalText(v); ok {
+			return s, err
+		}
+	case "BYTES":
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return base64.StdEncoding.EncodeToString(v.Bytes()), nil
+		}
+	case "TIMESTAMP":
+		return encodeTime(v, "2006-01-02T15:04:05.000")
+	case "DATE":
+		return encodeTime(v, "2006-01-02")
+	case "TIME":
+		return encodeTime(v, "15:04:05.000")
+	case "ARRAY":
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			member := schema.Member()
+			out := make([]interface{}, v.Len())
+			for i := range out {
+				elem, err := encodeValue(v.Index(i), member)
+				if err != nil {
+					return nil, fmt.Errorf("element %d: %w", i, err)
+				}
+				out[i] = elem
+			}
+			return out, nil
+		}
+	case "MAP":
+		if v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String {
+			member := schema.Member()
+			out := make(map[string]interface{}, v.Len())
+			iter := v.MapRange()
+			for iter.Next() {
+				elem, err := encodeValue(iter.Value(), member)
+				if err != nil {
+					return nil, fmt.Errorf("key %s: %w", iter.Key().String(), err)
+				}
+				out[iter.Key().String()] = elem
+			}
+			return out, nil
+		}
+	case "STRUCT":
+		if v.Kind() == reflect.Struct || v.Kind() == reflect.Map {
+			return encodeStruct(v, schema)
+		}
+	default:
+		// unknown types are passed through for the server to validate
+		return v.Interface(), nil
+	}
+	return nil, fmt.Errorf("cannot convert %s to %s", v.Type(), schema.Type)
+}
+
+// integerValue returns the value of any integer kind, as well as whole numbers decoded from JSON
+func integerValue(v reflect.Value) (int64, bool) {
+	if v.Type() == numberType {
+		n, err := v.Interface().(json.Number).Int64()
+		return n, err == nil
+	}
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if f := v.Float(); f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+			return int64(f), true
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u := v.Uint(); u <= math.MaxInt64 {
+			return int64(u), true
+		}
+	}
+	return 0, false
+}
+
+// marshalText returns the text representation of a value implementing encoding.TextMarshaler
+func marshalText(v reflect.Value) (string, bool, error) {
+	if !v.Type().Implements(textMarshalerType) {
+		return "", false, nil
+	}
+	b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+	return string(b), true, err
+}
+
+// encodeDecimal converts numbers, numeric strings and decimal types implementing encoding.TextMarshaler into a JSON number without losing precision
+func encodeDecimal(v reflect.Value) (interface{}, error) {
+	var s string
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, _ := integerValue(v)
+		return n, nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		s = v.String()
+	default:
+		text, ok, err := marshalText(v)
+		if !ok {
+			return nil, fmt.Errorf("cannot convert %s to DECIMAL", v.Type())
+		}
+		if err != nil {
+			return nil, err
+		}
+		s = text
+	}
+	if _, err := strconv.ParseFloat(s, 64); err != nil {
+		return nil, fmt.Errorf("%q is not a valid DECIMAL", s)
+	}
+	return json.Number(s), nil
+}
+
+// encodeTime formats a time.Time in UTC using the given layout. Integers are passed through as milliseconds since the epoch, and strings are passed through for the server to parse.
+func encodeTime(v reflect.Value, layout string) (interface{}, error) {
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).UTC().Format(layout), nil
+	}
+	if v.Kind() == reflect.String && v.Type() != numberType {
+		return v.String(), nil
+	}
+	if n, ok := integerValue(v); ok {
+		return n, nil
+	}
+	return nil, fmt.Errorf("cannot convert %s to a time", v.Type())
+}
+
+// encodeStruct converts a struct, or a map keyed by field name, into a STRUCT value
+func encodeStruct(v reflect.Value, schema Schema) (interface{}, error) {
+	values, err := rowValues(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]Field, len(schema.Fields))
+	for _, f := range schema.Fields {
+		fields[strings.ToUpper(f.Name)] = f
+	}
+	out := make(map[string]interface{}, len(values))
+	for name, fv := range values {
+		f, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %s", name)
+		}
+		elem, err := encodeValue(fv, f.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		out[f.Name] = elem
+	}
+	return out, nil
+}
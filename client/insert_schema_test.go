@@ -0,0 +1,185 @@
+package client
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vancelongwill/ksql-go/client/internal/testutils"
+)
+
+// testDecimal mimics third party decimal types, which marshal to text
+type testDecimal string
+
+func (d testDecimal) MarshalText() ([]byte, error) {
+	return []byte(d), nil
+}
+
+func TestInsertSchema(t *testing.T) {
+	schema := newInsertSchema(SourceDescription{
+		Fields: []Field{
+			{Name: "ID", Schema: Schema{Type: "STRING"}, Type: keyFieldType},
+			{Name: "QUANTITY", Schema: Schema{Type: "INTEGER"}},
+			{Name: "TOTAL", Schema: Schema{Type: "BIGINT"}},
+			{Name: "PRICE", Schema: Schema{Type: "DECIMAL"}},
+			{Name: "RATING", Schema: Schema{Type: "DOUBLE"}},
+			{Name: "CREATED_AT", Schema: Schema{Type: "TIMESTAMP"}},
+			{Name: "DAY", Schema: Schema{Type: "DATE"}},
+			{Name: "PAYLOAD", Schema: Schema{Type: "BYTES"}},
+			{Name: "NOTE", Schema: Schema{Type: "STRING"}},
+			{Name: "TAGS", Schema: Schema{Type: "ARRAY", MemberSchema: map[string]interface{}{"type": "STRING"}}},
+			{Name: "ADDRESS", Schema: Schema{Type: "STRUCT", Fields: []Field{
+				{Name: "CITY", Schema: Schema{Type: "STRING"}},
+			}}},
+		},
+	})
+	type address struct {
+		City string `ksql:"city"`
+	}
+	type order struct {
+		ID        string         `ksql:"id"`
+		Quantity  int            `ksql:"quantity"`
+		Total     uint16         `ksql:"total"`
+		Price     testDecimal    `ksql:"price"`
+		Rating    int            `ksql:"rating"`
+		CreatedAt time.Time      `ksql:"created_at"`
+		Day       *time.Time     `ksql:"day"`
+		Payload   []byte         `ksql:"payload"`
+		Note      sql.NullString `ksql:"note"`
+		Tags      []string       `ksql:"tags"`
+		Address   address        `ksql:"address"`
+	}
+	createdAt := time.Date(2021, 2, 3, 4, 5, 6, 7000000, time.FixedZone("CET", 3600))
+
+	t.Run("it should convert a struct to the wire representation of each column", func(t *testing.T) {
+		row, err := schema.encode(&order{
+			ID:        "o1",
+			Quantity:  2,
+			Total:     3,
+			Price:     "1.10",
+			Rating:    4,
+			CreatedAt: createdAt,
+			Payload:   []byte("hi"),
+			Tags:      []string{"a"},
+			Address:   address{City: "London"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"ID":         "o1",
+			"QUANTITY":   int64(2),
+			"TOTAL":      int64(3),
+			"PRICE":      json.Number("1.10"),
+			"RATING":     float64(4),
+			"CREATED_AT": "2021-02-03T03:05:06.007",
+			"DAY":        nil,
+			"PAYLOAD":    "aGk=",
+			"NOTE":       nil,
+			"TAGS":       []interface{}{"a"},
+			"ADDRESS":    map[string]interface{}{"CITY": "London"},
+		}, row)
+		b, err := json.Marshal(row)
+		assert.NoError(t, err)
+		assert.Contains(t, string(b), `"PRICE":1.10`, "decimals should be sent as numbers without losing precision")
+	})
+
+	t.Run("it should accept maps keyed by column name", func(t *testing.T) {
+		row, err := schema.encode(map[string]interface{}{"id": "o1", "quantity": 2})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"ID": "o1", "QUANTITY": int64(2)}, row)
+	})
+
+	t.Run("it should accept numbers decoded from JSON", func(t *testing.T) {
+		row, err := schema.encode(map[string]interface{}{
+			"id":       "o1",
+			"quantity": float64(2),
+			"total":    json.Number("3"),
+			"rating":   json.Number("4.5"),
+			"price":    json.Number("1.10"),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"ID":       "o1",
+			"QUANTITY": int64(2),
+			"TOTAL":    int64(3),
+			"RATING":   4.5,
+			"PRICE":    json.Number("1.10"),
+		}, row)
+	})
+
+	testCases := []struct {
+		name string
+		row  interface{}
+	}{
+		{"a missing key column", map[string]interface{}{"quantity": 2}},
+		{"a null key column", map[string]interface{}{"id": nil}},
+		{"an unknown column", map[string]interface{}{"id": "o1", "colour": "red"}},
+		{"a type mismatch", map[string]interface{}{"id": "o1", "quantity": "two"}},
+		{"a fractional INTEGER", map[string]interface{}{"id": "o1", "quantity": 1.5}},
+		{"an overflowing INTEGER", map[string]interface{}{"id": "o1", "quantity": int64(1) << 40}},
+		{"an invalid DECIMAL", map[string]interface{}{"id": "o1", "price": "abc"}},
+		{"a nested type mismatch", map[string]interface{}{"id": "o1", "tags": []int{1}}},
+		{"an unknown STRUCT field", map[string]interface{}{"id": "o1", "address": map[string]string{"street": "x"}}},
+		{"a value which isn't a row", "o1"},
+	}
+	for _, tc := range testCases {
+		t.Run("it should reject "+tc.name, func(t *testing.T) {
+			_, err := schema.encode(tc.row)
+			assert.True(t, errors.Is(err, ErrInvalidRow), "got %v", err)
+		})
+	}
+}
+
+func TestInsertsStreamWithSchemaValidation(t *testing.T) {
+	type dataRow struct {
+		K  string `ksql:"k"`
+		V1 int    `ksql:"v1"`
+	}
+	describe := []ExecResult{{
+		DescribeResult: &DescribeResult{
+			SourceDescription: SourceDescription{
+				Name: "S1",
+				Fields: []Field{
+					{Name: "K", Schema: Schema{Type: "STRING"}, Type: keyFieldType},
+					{Name: "V1", Schema: Schema{Type: "INTEGER"}},
+				},
+			},
+		},
+	}}
+	srv := testutils.Server("/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case execPath:
+			var p ExecPayload
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+			assert.Equal(t, "DESCRIBE s1;", p.KSQL)
+			assert.NoError(t, json.NewEncoder(w).Encode(describe))
+		case insertsStreamPath:
+			dec := json.NewDecoder(r.Body)
+			assert.NoError(t, dec.Decode(new(InsertsStreamTargetPayload)))
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			var row map[string]interface{}
+			assert.NoError(t, dec.Decode(&row))
+			assert.Equal(t, map[string]interface{}{"K": "a", "V1": float64(1)}, row)
+			assert.NoError(t, json.NewEncoder(w).Encode(InsertsStreamAck{Status: "ok", Seq: 0}))
+			w.(http.Flusher).Flush()
+			// wait for the client to end the stream
+			for dec.Decode(new(interface{})) == nil {
+			}
+		}
+	})
+	srv.StartTLS()
+	defer srv.Close()
+	c := New(srv.URL, WithHTTPClient(testutils.Client()))
+	ctx := context.Background()
+	wtr, err := c.InsertsStream(ctx, InsertsStreamTargetPayload{Target: "s1"}, WithSchemaValidation())
+	assert.NoError(t, err)
+	defer wtr.Close()
+	err = wtr.WriteJSON(ctx, map[string]interface{}{"v1": 1})
+	assert.True(t, errors.Is(err, ErrInvalidRow), "rows missing a key should not be sent")
+	assert.NoError(t, wtr.WriteJSON(ctx, dataRow{K: "a", V1: 1}))
+}
@@ -6,56 +6,386 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"sync"
+	"time"
 )
 
-// ErrAckUnsucessful signifies that the document couldn't be written the the stream
-var ErrAckUnsucessful = errors.New("an ack was received but the status was not 'ok'")
+var (
+	// ErrAckUnsucessful signifies that the document couldn't be written the the stream. Rejected rows fail with an *InsertError, which matches ErrAckUnsucessful using errors.Is.
+	ErrAckUnsucessful = errors.New("an ack was received but the status was not 'ok'")
+	// ErrWriterClosed is returned when writing to an inserts stream which has been closed
+	ErrWriterClosed = errors.New("inserts stream writer closed")
+	// ErrCloseTimeout is returned by Close when the server doesn't end the stream in time, in which case the connection is closed without waiting for the remaining acks
+	ErrCloseTimeout = errors.New("timed out waiting for the inserts stream to end")
+)
+
+const (
+	// DefaultMaxInFlight is the default maximum number of rows which can be written to an inserts stream before their acks are received
+	DefaultMaxInFlight = 1000
+	// DefaultCloseTimeout is the default time Close waits for the server to acknowledge the remaining rows and end the stream
+	DefaultCloseTimeout = 30 * time.Second
+)
+
+// InsertsStreamOption configures an inserts stream
+type InsertsStreamOption func(*insertsStreamConfig)
+
+type insertsStreamConfig struct {
+	maxInFlight  int
+	closeTimeout time.Duration
+	deadLetter   func(*InsertError)
+	validate     bool
+	// schema is fetched when the stream is opened if validate is set, unless it was given by WithSchema
+	schema *insertSchema
+}
+
+func newInsertsStreamConfig(options ...InsertsStreamOption) insertsStreamConfig {
+	conf := insertsStreamConfig{maxInFlight: DefaultMaxInFlight, closeTimeout: DefaultCloseTimeout}
+	for _, opt := range options {
+		opt(&conf)
+	}
+	return conf
+}
+
+// WithMaxInFlight limits the number of rows which can be awaiting an ack at any one time.
+//
+// Once the limit is reached, writes block until an ack is received, applying backpressure to the writer.
+func WithMaxInFlight(n int) InsertsStreamOption {
+	return func(c *insertsStreamConfig) {
+		if n > 0 {
+			c.maxInFlight = n
+		}
+	}
+}
+
+// WithCloseTimeout limits the time Close waits for the server to acknowledge the remaining rows and end the stream, after which the connection is closed and the remaining rows fail
+func WithCloseTimeout(d time.Duration) InsertsStreamOption {
+	return func(c *insertsStreamConfig) {
+		if d > 0 {
+			c.closeTimeout = d
+		}
+	}
+}
+
+// WithDeadLetter registers a callback which receives every row that fails to be inserted, whether it was rejected by the server or lost because the stream failed.
+//
+// The callback is called before the row's InsertAck resolves, from the goroutine which reads acks, or from the writing goroutine when a write to the stream fails, so it should not block for long.
+func WithDeadLetter(fn func(*InsertError)) InsertsStreamOption {
+	return func(c *insertsStreamConfig) {
+		c.deadLetter = fn
+	}
+}
+
+// WithSchemaValidation fetches the schema of the target stream when the inserts stream is opened, and checks each row against it before it is sent.
+//
+// Rows must be structs, which are mapped to columns using their `ksql` tags in the same way as ScanStruct, or maps keyed by column name.
+// Values are converted to the representation expected by ksqlDB, e.g. a time.Time is formatted for TIMESTAMP, DATE and TIME columns and a []byte is base64 encoded for BYTES columns.
+// Rows with unknown columns, key columns which are absent or null, or values which can't be converted to the column type, fail with ErrInvalidRow without being sent.
+func WithSchemaValidation() InsertsStreamOption {
+	return func(c *insertsStreamConfig) {
+		c.validate = true
+	}
+}
+
+// WithSchema checks each row against the schema of an already described target in the same way as WithSchemaValidation, without describing the target again
+func WithSchema(desc SourceDescription) InsertsStreamOption {
+	return func(c *insertsStreamConfig) {
+		c.validate = true
+		c.schema = newInsertSchema(desc)
+	}
+}
 
-// InsertsStreamWriter represents an inserts stream
+// InsertError is returned for a row which couldn't be inserted into the stream
+type InsertError struct {
+	// Seq is the sequence number of the row in the stream
+	Seq int64
+	// Code and Message are the error code and message sent by the server when it rejects a row
+	Code    int
+	Message string
+	// Row is the original value passed to WriteAsync or WriteJSON
+	Row interface{}
+	// Err is set when the row was lost because the stream failed, rather than being rejected by the server
+	Err error
+}
+
+func (e *InsertError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("insert %d failed: %s", e.Seq, e.Err)
+	}
+	return fmt.Sprintf("insert %d rejected with error code %d: %s", e.Seq, e.Code, e.Message)
+}
+
+// Unwrap returns the stream failure, if any
+func (e *InsertError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the row was rejected by the server, for compatibility with ErrAckUnsucessful
+func (e *InsertError) Is(target error) bool {
+	return target == ErrAckUnsucessful && e.Err == nil
+}
+
+// InsertAck is a future for the acknowledgement of a single row written to an inserts stream
+type InsertAck struct {
+	// Seq is the sequence number of the row in the stream
+	Seq  int64
+	row  interface{}
+	done chan struct{}
+	err  error
+}
+
+func newInsertAck(seq int64, row interface{}) *InsertAck {
+	return &InsertAck{Seq: seq, row: row, done: make(chan struct{})}
+}
+
+func (a *InsertAck) resolve(err error) {
+	a.err = err
+	close(a.done)
+}
+
+// Done returns a channel which is closed once the ack has been received, or the stream has failed
+func (a *InsertAck) Done() <-chan struct{} {
+	return a.done
+}
+
+// Err returns the result of the insert. It should only be called once Done is closed.
+func (a *InsertAck) Err() error {
+	return a.err
+}
+
+// Wait blocks until the ack has been received or the context is done
+func (a *InsertAck) Wait(ctx context.Context) error {
+	select {
+	case <-a.done:
+		return a.err
+	case <-ctx.Done():
+		return fmt.Errorf("context was cancelled before ack received: %w", ctx.Err())
+	}
+}
+
+// InsertsStreamWriter represents an inserts stream. It is safe for concurrent use by multiple goroutines.
+//
+// Rows are marshalled concurrently but written one at a time, each being assigned the next sequence number, and a single dispatch goroutine reads the acks from the server and routes each one to the write with the matching sequence number.
 type InsertsStreamWriter struct {
-	mu     sync.Mutex
-	enc    *json.Encoder
-	ackMap map[int64]string
-	curr   int64
-	ackCh  <-chan InsertsStreamAck
-	errCh  <-chan error
+	// writeMu serialises writes to the stream so that sequence numbers match the order of the rows
+	writeMu sync.Mutex
+	w       io.Writer
+
+	// mu guards the pending acks, and is never held while blocked on the network
+	mu      sync.Mutex
+	curr    int64
+	pending map[int64]*InsertAck
+	// slots holds a token for each row awaiting an ack
+	slots chan struct{}
+	// err is set once the stream has failed or been closed
+	err          error
+	deadLetter   func(*InsertError)
+	schema       *insertSchema
+	closeTimeout time.Duration
+
+	acks   io.Reader
+	req    io.Closer
 	closer io.Closer
+	// done is closed once the dispatch goroutine has exited
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+	// release is called once the writer is closed, to stop the client tracking it
+	release func()
+}
+
+// newInsertsStreamWriter creates a writer which writes rows to w and reads acks from acks.
+//
+// Closing req ends the request, after which the server acks any remaining rows and ends the response. Finally closer releases the underlying connection.
+func newInsertsStreamWriter(w io.Writer, acks io.Reader, req io.Closer, closer io.Closer, conf insertsStreamConfig) *InsertsStreamWriter {
+	i := &InsertsStreamWriter{
+		w:       w,
+		pending: make(map[int64]*InsertAck),
+		slots:   make(chan struct{}, conf.maxInFlight),
+		acks:    acks,
+		req:     req,
+		closer:  closer,
+		done:    make(chan struct{}),
+
+		deadLetter:   conf.deadLetter,
+		schema:       conf.schema,
+		closeTimeout: conf.closeTimeout,
+	}
+	go i.dispatch()
+	return i
+}
+
+// dispatch routes each ack received from the server to the waiting writer
+func (i *InsertsStreamWriter) dispatch() {
+	defer close(i.done)
+	dec := json.NewDecoder(i.acks)
+	for {
+		var ack InsertsStreamAck
+		if err := dec.Decode(&ack); err != nil {
+			i.mu.Lock()
+			closed := i.err == ErrWriterClosed
+			i.mu.Unlock()
+			switch {
+			case closed:
+				err = ErrWriterClosed
+			case err == io.EOF:
+				// the server ended the stream while rows were still awaiting acks
+				err = io.ErrUnexpectedEOF
+			}
+			i.failPending(err)
+			// unblock any write in progress, as nothing will read the rest of the request once the response has ended
+			_ = i.req.Close()
+			return
+		}
+		i.mu.Lock()
+		a, exists := i.pending[ack.Seq]
+		delete(i.pending, ack.Seq)
+		i.mu.Unlock()
+		if !exists {
+			continue
+		}
+		if ack.Status != "ok" {
+			insertErr := &InsertError{Seq: a.Seq, Code: ack.ErrorCode, Message: ack.Message, Row: a.row}
+			i.sendToDeadLetter(insertErr)
+			a.resolve(insertErr)
+		} else {
+			a.resolve(nil)
+		}
+		<-i.slots
+	}
+}
+
+// failPending resolves every row which is still awaiting an ack with an *InsertError wrapping err, and fails any further writes
+func (i *InsertsStreamWriter) failPending(err error) {
+	i.mu.Lock()
+	if i.err == nil {
+		i.err = err
+	}
+	failed := make([]*InsertAck, 0, len(i.pending))
+	for seq, a := range i.pending {
+		delete(i.pending, seq)
+		<-i.slots
+		failed = append(failed, a)
+	}
+	i.mu.Unlock()
+	sort.Slice(failed, func(x, y int) bool { return failed[x].Seq < failed[y].Seq })
+	for _, a := range failed {
+		insertErr := &InsertError{Seq: a.Seq, Row: a.row, Err: err}
+		i.sendToDeadLetter(insertErr)
+		a.resolve(insertErr)
+	}
+}
+
+func (i *InsertsStreamWriter) sendToDeadLetter(err *InsertError) {
+	if i.deadLetter != nil {
+		i.deadLetter(err)
+	}
+}
+
+// WriteAsync encodes and writes p to the inserts stream without waiting for the corresponding ack.
+//
+// It blocks while the maximum number of rows are in flight. The returned InsertAck resolves once the server has acknowledged the row, failing with an *InsertError if the server rejected it or the stream failed first.
+func (i *InsertsStreamWriter) WriteAsync(ctx context.Context, p interface{}) (*InsertAck, error) {
+	b, err := marshalRow(i.schema, p)
+	if err != nil {
+		return nil, err
+	}
+	b = append(b, '\n')
+	select {
+	case i.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("context was cancelled while waiting for rows in flight: %w", ctx.Err())
+	}
+	i.writeMu.Lock()
+	defer i.writeMu.Unlock()
+	i.mu.Lock()
+	if i.err != nil {
+		err := i.err
+		i.mu.Unlock()
+		<-i.slots
+		return nil, err
+	}
+	// the ack is registered before writing in case it arrives before the write returns
+	a := newInsertAck(i.curr, p)
+	i.pending[a.Seq] = a
+	i.curr++
+	i.mu.Unlock()
+	if _, err := i.w.Write(b); err != nil {
+		// a partial write leaves the stream in an unknown state, so no further rows can be written
+		i.failPending(err)
+		return nil, err
+	}
+	return a, nil
+}
+
+// marshalRow encodes a row, converting it to match the schema of the target first if there is one
+func marshalRow(schema *insertSchema, p interface{}) ([]byte, error) {
+	if schema == nil {
+		return json.Marshal(p)
+	}
+	row, err := schema.encode(p)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(row)
 }
 
 // WriteJSON encodes and writes p to the inserts stream, and waits for the corresponding Ack to be received
 func (i *InsertsStreamWriter) WriteJSON(ctx context.Context, p interface{}) error {
-	i.mu.Lock()
-	curr := i.curr
-	if err := i.enc.Encode(&p); err != nil {
+	a, err := i.WriteAsync(ctx, p)
+	if err != nil {
 		return err
 	}
-	i.curr++
+	return a.Wait(ctx)
+}
+
+// Flush waits until every row written so far has been acknowledged, returning the first failure
+func (i *InsertsStreamWriter) Flush(ctx context.Context) error {
+	i.mu.Lock()
+	acks := make([]*InsertAck, 0, len(i.pending))
+	for _, a := range i.pending {
+		acks = append(acks, a)
+	}
 	i.mu.Unlock()
-	// @TODO: cleaner way to process acks
-	for {
-		// check if the ack has been received in another goroutine
-		if a, ok := i.ackMap[curr]; ok {
-			if a != "ok" {
-				return ErrAckUnsucessful
-			}
-			delete(i.ackMap, curr)
-			return nil
-		}
-		select {
-		case ack, ok := <-i.ackCh:
-			if ok {
-				i.ackMap[ack.Seq] = ack.Status
-			}
-		case err := <-i.errCh:
-			return err
-		case <-ctx.Done():
-			return fmt.Errorf("context was cancelled before ack received: %w", ctx.Err())
+	var firstErr error
+	for _, a := range acks {
+		if err := a.Wait(ctx); err != nil && firstErr == nil {
+			firstErr = err
 		}
 	}
+	return firstErr
 }
 
-// Close terminates the request and therefore inserts stream
+// Close ends the inserts stream. Rows which have already been written are still acknowledged before the stream terminates, and any which aren't fail with an *InsertError wrapping ErrWriterClosed.
+//
+// Writes made after Close fail with ErrWriterClosed. If the server doesn't end the stream within the close timeout, see WithCloseTimeout, the connection is closed and ErrCloseTimeout is returned. Calling Close more than once is a no-op.
 func (i *InsertsStreamWriter) Close() error {
-	return i.closer.Close()
+	i.closeOnce.Do(func() {
+		i.mu.Lock()
+		if i.err == nil {
+			i.err = ErrWriterClosed
+		}
+		i.mu.Unlock()
+		if err := i.req.Close(); err != nil {
+			i.closeErr = err
+		}
+		timer := time.NewTimer(i.closeTimeout)
+		defer timer.Stop()
+		select {
+		case <-i.done:
+		case <-timer.C:
+			// closing the connection ends the response, which stops the dispatch goroutine
+			if i.closeErr == nil {
+				i.closeErr = ErrCloseTimeout
+			}
+		}
+		if err := i.closer.Close(); err != nil && i.closeErr == nil {
+			i.closeErr = err
+		}
+		<-i.done
+		if i.release != nil {
+			i.release()
+		}
+	})
+	return i.closeErr
 }
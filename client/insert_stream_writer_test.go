@@ -5,24 +5,54 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"io/ioutil"
+	"math/rand"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+// newTestWriter creates a writer which writes rows to out. Acks are sent to the writer using the returned encoder.
+//
+// Closing the writer's request ends the ack stream, as a server would.
+func newTestWriter(out io.Writer, options ...InsertsStreamOption) (*InsertsStreamWriter, *json.Encoder, *io.PipeWriter) {
+	ackR, ackW := io.Pipe()
+	wtr := newInsertsStreamWriter(
+		out,
+		ackR,
+		closerFunc(func() error { return ackW.Close() }),
+		ioutil.NopCloser(nil),
+		newInsertsStreamConfig(options...),
+	)
+	return wtr, json.NewEncoder(ackW), ackW
+}
+
+// assertLost checks that a row failed with an *InsertError caused by the stream failing with want
+func assertLost(t *testing.T, want error, err error) {
+	t.Helper()
+	var insertErr *InsertError
+	if assert.True(t, errors.As(err, &insertErr), "expected an *InsertError, got %v", err) {
+		assert.Equal(t, want, insertErr.Err)
+		assert.False(t, errors.Is(err, ErrAckUnsucessful), "the row wasn't rejected by the server")
+	}
+}
+
 func TestInsertStreamWriter(t *testing.T) {
 	t.Run("Writes the json to the underlying writer", func(t *testing.T) {
 		ctx := context.Background()
 		b := &bytes.Buffer{}
-		enc := json.NewEncoder(b)
-		wtr := &InsertsStreamWriter{
-			enc:    enc,
-			ackMap: map[int64]string{0: "ok"},
-			closer: ioutil.NopCloser(b),
-		}
+		wtr, acks, _ := newTestWriter(b)
 		defer wtr.Close()
+		go acks.Encode(InsertsStreamAck{Status: "ok", Seq: 0})
 		in := map[string]string{"test": "ok"}
 		err := wtr.WriteJSON(ctx, &in)
 		assert.NoError(t, err)
@@ -35,11 +65,7 @@ func TestInsertStreamWriter(t *testing.T) {
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
 		defer cancel()
 		b := &bytes.Buffer{}
-		enc := json.NewEncoder(b)
-		wtr := &InsertsStreamWriter{
-			enc:    enc,
-			closer: ioutil.NopCloser(b),
-		}
+		wtr, _, _ := newTestWriter(b)
 		defer wtr.Close()
 		in := map[string]string{"test": "ok"}
 		err := wtr.WriteJSON(ctx, &in)
@@ -53,18 +79,11 @@ func TestInsertStreamWriter(t *testing.T) {
 	t.Run("Succeedes when the corresponding ACK is received", func(t *testing.T) {
 		ctx := context.Background()
 		b := &bytes.Buffer{}
-		enc := json.NewEncoder(b)
-		ackCh := make(chan InsertsStreamAck)
-		wtr := &InsertsStreamWriter{
-			enc:    enc,
-			ackMap: map[int64]string{},
-			closer: ioutil.NopCloser(b),
-			ackCh:  ackCh,
-		}
+		wtr, acks, _ := newTestWriter(b)
 		// send the corresponding ack 100 milliseconds later
 		go func() {
 			time.Sleep(100 * time.Millisecond)
-			ackCh <- InsertsStreamAck{Status: "ok", Seq: 0}
+			acks.Encode(InsertsStreamAck{Status: "ok", Seq: 0})
 		}()
 		defer wtr.Close()
 		in := map[string]string{"test": "ok"}
@@ -75,4 +94,309 @@ func TestInsertStreamWriter(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, in, out)
 	})
+	t.Run("Fails when the ACK status is not ok", func(t *testing.T) {
+		wtr, acks, _ := newTestWriter(ioutil.Discard)
+		defer wtr.Close()
+		go acks.Encode(InsertsStreamAck{Status: "error", Seq: 0, ErrorCode: 40000, Message: "Invalid value"})
+		row := map[string]string{"test": "ok"}
+		err := wtr.WriteJSON(context.Background(), row)
+		assert.True(t, errors.Is(err, ErrAckUnsucessful))
+		var insertErr *InsertError
+		assert.True(t, errors.As(err, &insertErr))
+		assert.Equal(t, &InsertError{Seq: 0, Code: 40000, Message: "Invalid value", Row: row}, insertErr)
+	})
+	t.Run("Fails when the row can't be encoded", func(t *testing.T) {
+		wtr, acks, _ := newTestWriter(ioutil.Discard)
+		defer wtr.Close()
+		err := wtr.WriteJSON(context.Background(), make(chan int))
+		assert.Error(t, err)
+		go acks.Encode(InsertsStreamAck{Status: "ok", Seq: 0})
+		a, err := wtr.WriteAsync(context.Background(), "next")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), a.Seq, "the failed row should not use up a sequence number")
+		assert.NoError(t, a.Wait(context.Background()))
+	})
+	t.Run("Fails all pending rows when the stream can't be written to", func(t *testing.T) {
+		rowsR, rowsW := io.Pipe()
+		wtr, _, _ := newTestWriter(rowsW)
+		defer wtr.Close()
+		go json.NewDecoder(rowsR).Decode(new(interface{}))
+		first, err := wtr.WriteAsync(context.Background(), "first")
+		assert.NoError(t, err)
+		writeErr := errors.New("connection reset")
+		rowsR.CloseWithError(writeErr)
+		_, err = wtr.WriteAsync(context.Background(), "second")
+		assert.Equal(t, writeErr, err)
+		assertLost(t, writeErr, first.Wait(context.Background()))
+	})
+}
+
+func TestInsertStreamWriterAsync(t *testing.T) {
+	t.Run("WriteAsync resolves acks received out of order", func(t *testing.T) {
+		wtr, acks, _ := newTestWriter(ioutil.Discard)
+		defer wtr.Close()
+		ctx := context.Background()
+		first, err := wtr.WriteAsync(ctx, "first")
+		assert.NoError(t, err)
+		second, err := wtr.WriteAsync(ctx, "second")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), first.Seq)
+		assert.Equal(t, int64(1), second.Seq)
+		assert.NoError(t, acks.Encode(InsertsStreamAck{Status: "ok", Seq: 1}))
+		<-second.Done()
+		assert.NoError(t, second.Err())
+		select {
+		case <-first.Done():
+			t.Fatal("the first ack should still be pending")
+		default:
+		}
+		assert.NoError(t, acks.Encode(InsertsStreamAck{Status: "ok", Seq: 0}))
+		assert.NoError(t, first.Wait(ctx))
+	})
+	t.Run("WriteAsync blocks when the maximum number of rows are in flight", func(t *testing.T) {
+		wtr, acks, _ := newTestWriter(ioutil.Discard, WithMaxInFlight(1))
+		defer wtr.Close()
+		_, err := wtr.WriteAsync(context.Background(), "first")
+		assert.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err = wtr.WriteAsync(ctx, "second")
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.NoError(t, acks.Encode(InsertsStreamAck{Status: "ok", Seq: 0}))
+		second, err := wtr.WriteAsync(context.Background(), "second")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), second.Seq)
+	})
+	t.Run("Flush waits for all outstanding acks", func(t *testing.T) {
+		wtr, acks, _ := newTestWriter(ioutil.Discard)
+		defer wtr.Close()
+		ctx := context.Background()
+		for i := 0; i < 3; i++ {
+			_, err := wtr.WriteAsync(ctx, i)
+			assert.NoError(t, err)
+		}
+		go func() {
+			for i := 2; i >= 0; i-- {
+				acks.Encode(InsertsStreamAck{Status: "ok", Seq: int64(i)})
+			}
+		}()
+		assert.NoError(t, wtr.Flush(ctx))
+	})
+	t.Run("pending acks fail when the stream fails", func(t *testing.T) {
+		wtr, _, ackW := newTestWriter(ioutil.Discard)
+		defer wtr.Close()
+		a, err := wtr.WriteAsync(context.Background(), "first")
+		assert.NoError(t, err)
+		streamErr := errors.New("stream reset")
+		ackW.CloseWithError(streamErr)
+		assertLost(t, streamErr, a.Wait(context.Background()))
+		_, err = wtr.WriteAsync(context.Background(), "second")
+		assert.Equal(t, streamErr, err, "writes should fail once the stream has failed")
+	})
+	t.Run("pending acks fail when the server ends the stream", func(t *testing.T) {
+		wtr, _, ackW := newTestWriter(ioutil.Discard)
+		defer wtr.Close()
+		a, err := wtr.WriteAsync(context.Background(), "first")
+		assert.NoError(t, err)
+		ackW.Close()
+		assertLost(t, io.ErrUnexpectedEOF, a.Wait(context.Background()))
+	})
+}
+
+func TestInsertStreamWriterDeadLetter(t *testing.T) {
+	t.Run("rejected rows are sent to the dead letter callback", func(t *testing.T) {
+		var dead []*InsertError
+		wtr, acks, _ := newTestWriter(ioutil.Discard, WithDeadLetter(func(e *InsertError) {
+			dead = append(dead, e)
+		}))
+		defer wtr.Close()
+		ctx := context.Background()
+		first, err := wtr.WriteAsync(ctx, "first")
+		assert.NoError(t, err)
+		second, err := wtr.WriteAsync(ctx, "second")
+		assert.NoError(t, err)
+		assert.NoError(t, acks.Encode(InsertsStreamAck{Status: "ok", Seq: 0}))
+		assert.NoError(t, acks.Encode(InsertsStreamAck{Status: "error", Seq: 1, ErrorCode: 40000, Message: "Invalid value"}))
+		assert.NoError(t, first.Wait(ctx))
+		assert.Error(t, second.Wait(ctx))
+		assert.NoError(t, wtr.Flush(ctx))
+		assert.Equal(t, []*InsertError{
+			{Seq: 1, Code: 40000, Message: "Invalid value", Row: "second"},
+		}, dead)
+	})
+	t.Run("rows lost when the stream fails are sent to the dead letter callback", func(t *testing.T) {
+		dead := make(chan *InsertError, 2)
+		wtr, _, ackW := newTestWriter(ioutil.Discard, WithDeadLetter(func(e *InsertError) {
+			dead <- e
+		}))
+		defer wtr.Close()
+		ctx := context.Background()
+		for _, row := range []string{"first", "second"} {
+			_, err := wtr.WriteAsync(ctx, row)
+			assert.NoError(t, err)
+		}
+		streamErr := errors.New("stream reset")
+		ackW.CloseWithError(streamErr)
+		for i, row := range []string{"first", "second"} {
+			e := <-dead
+			assert.Equal(t, int64(i), e.Seq)
+			assert.Equal(t, row, e.Row)
+			assert.True(t, errors.Is(e, streamErr))
+			assert.False(t, errors.Is(e, ErrAckUnsucessful), "the row wasn't rejected by the server")
+		}
+	})
+}
+
+func TestInsertStreamWriterClose(t *testing.T) {
+	wtr, acks, _ := newTestWriter(ioutil.Discard)
+	ctx := context.Background()
+	first, err := wtr.WriteAsync(ctx, "first")
+	assert.NoError(t, err)
+	second, err := wtr.WriteAsync(ctx, "second")
+	assert.NoError(t, err)
+	assert.NoError(t, acks.Encode(InsertsStreamAck{Status: "ok", Seq: 0}))
+	assert.NoError(t, first.Wait(ctx))
+
+	assert.NoError(t, wtr.Close())
+	assertLost(t, ErrWriterClosed, second.Wait(ctx))
+	_, err = wtr.WriteAsync(ctx, "third")
+	assert.Equal(t, ErrWriterClosed, err)
+	assert.NoError(t, wtr.Close(), "closing twice should be a no-op")
+}
+
+func TestInsertStreamWriterCloseTimeout(t *testing.T) {
+	// the server never ends the response, until the connection is closed
+	ackR, _ := io.Pipe()
+	wtr := newInsertsStreamWriter(
+		ioutil.Discard,
+		ackR,
+		closerFunc(func() error { return nil }),
+		closerFunc(func() error { return ackR.Close() }),
+		newInsertsStreamConfig(WithCloseTimeout(50*time.Millisecond)),
+	)
+	ctx := context.Background()
+	a, err := wtr.WriteAsync(ctx, "first")
+	assert.NoError(t, err)
+
+	start := time.Now()
+	assert.Equal(t, ErrCloseTimeout, wtr.Close())
+	assert.True(t, time.Since(start) < 5*time.Second, "Close should not wait for the server")
+	assertLost(t, ErrWriterClosed, a.Wait(ctx))
+}
+
+func TestInsertStreamWriterConcurrency(t *testing.T) {
+	const (
+		writers = 50
+		writes  = 20
+	)
+	rowsR, rowsW := io.Pipe()
+	wtr, acks, ackW := newTestWriter(rowsW)
+	// the server acks rows in a random order, in batches
+	go func() {
+		dec := json.NewDecoder(rowsR)
+		var batch []int64
+		for seq := int64(0); seq < writers*writes; seq++ {
+			var row map[string]int
+			if err := dec.Decode(&row); err != nil {
+				ackW.CloseWithError(err)
+				return
+			}
+			batch = append(batch, seq)
+			if len(batch) == 10 || seq == writers*writes-1 {
+				rand.Shuffle(len(batch), func(i, j int) { batch[i], batch[j] = batch[j], batch[i] })
+				for _, s := range batch {
+					acks.Encode(InsertsStreamAck{Status: "ok", Seq: s})
+				}
+				batch = batch[:0]
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*writes)
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < writes; i++ {
+				if i%2 == 0 {
+					errs <- wtr.WriteJSON(context.Background(), map[string]int{"writer": w, "i": i})
+					continue
+				}
+				a, err := wtr.WriteAsync(context.Background(), map[string]int{"writer": w, "i": i})
+				if err != nil {
+					errs <- err
+					continue
+				}
+				errs <- a.Wait(context.Background())
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, wtr.Flush(context.Background()))
+	assert.Equal(t, int64(writers*writes), wtr.curr)
+	assert.NoError(t, wtr.Close())
+}
+
+// ackServer simulates a server which acks each row written to the returned writer after the given latency
+func ackServer(latency time.Duration) (*InsertsStreamWriter, io.Closer) {
+	rowsR, rowsW := io.Pipe()
+	ackR, ackW := io.Pipe()
+	go func() {
+		var mu sync.Mutex
+		enc := json.NewEncoder(ackW)
+		dec := json.NewDecoder(rowsR)
+		for seq := int64(0); ; seq++ {
+			var row interface{}
+			if err := dec.Decode(&row); err != nil {
+				return
+			}
+			ack := InsertsStreamAck{Status: "ok", Seq: seq}
+			time.AfterFunc(latency, func() {
+				mu.Lock()
+				defer mu.Unlock()
+				enc.Encode(&ack)
+			})
+		}
+	}()
+	wtr := newInsertsStreamWriter(rowsW, ackR, rowsW, ackR, newInsertsStreamConfig())
+	return wtr, closerFunc(func() error {
+		ackW.Close()
+		return wtr.Close()
+	})
+}
+
+func BenchmarkInsertsStreamWriter(b *testing.B) {
+	const latency = time.Millisecond
+	row := map[string]interface{}{"K": "k1", "V1": 1, "V2": "a", "V3": true}
+	b.Run("WriteJSON", func(b *testing.B) {
+		wtr, closer := ackServer(latency)
+		defer closer.Close()
+		ctx := context.Background()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := wtr.WriteJSON(ctx, row); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("WriteAsync", func(b *testing.B) {
+		wtr, closer := ackServer(latency)
+		defer closer.Close()
+		ctx := context.Background()
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, err := wtr.WriteAsync(ctx, row); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := wtr.Flush(ctx); err != nil {
+			b.Fatal(err)
+		}
+	})
 }
@@ -1,7 +1,6 @@
 package client
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"io"
@@ -20,11 +19,14 @@ type InsertsStreamTargetPayload struct {
 type InsertsStreamAck struct {
 	Status string `json:"status"`
 	Seq    int64  `json:"seq"`
+	// ErrorCode and Message are only present when the row was rejected
+	ErrorCode int    `json:"error_code,omitempty"`
+	Message   string `json:"message,omitempty"`
 }
 
 // InsertsStreamCloser gracefully terminates the stream
 type InsertsStreamCloser struct {
-	req  io.ReadCloser
+	req  io.Closer
 	resp io.ReadCloser
 }
 
@@ -43,15 +45,20 @@ func (i *InsertsStreamCloser) Close() error {
 }
 
 // InsertsStream allows you to insert rows into an existing ksqlDB stream. The stream must have already been created in ksqlDB.
-func (c *ksqldb) InsertsStream(ctx context.Context, payload InsertsStreamTargetPayload) (*InsertsStreamWriter, error) {
+func (c *ksqldb) InsertsStream(ctx context.Context, payload InsertsStreamTargetPayload, options ...InsertsStreamOption) (*InsertsStreamWriter, error) {
+	conf := newInsertsStreamConfig(options...)
+	if conf.validate && conf.schema == nil {
+		schema, err := c.describeInsertSchema(ctx, payload.Target)
+		if err != nil {
+			return nil, err
+		}
+		conf.schema = schema
+	}
 	pr, pw := io.Pipe()
 	req, err := makeRequest(ctx, c.baseURL, insertsStreamPath, http.MethodPost, ioutil.NopCloser(pr))
 	if err != nil {
 		return nil, err
 	}
-	ackCh := make(chan InsertsStreamAck)
-	ackMap := make(map[int64]string)
-	errCh := make(chan error, 1)
 	enc := json.NewEncoder(pw)
 	g, _ := errgroup.WithContext(context.Background())
 	g.Go(func() error {
@@ -64,31 +71,7 @@ func (c *ksqldb) InsertsStream(ctx context.Context, payload InsertsStreamTargetP
 	if err := g.Wait(); err != nil {
 		return nil, err
 	}
-	go func() {
-		defer close(ackCh)
-		sc := bufio.NewScanner(res.Body)
-		for sc.Scan() {
-			var ack InsertsStreamAck
-			b := sc.Bytes()
-			if err := json.Unmarshal(b, &ack); err != nil {
-				errCh <- err
-				close(errCh)
-			}
-			ackCh <- ack
-		}
-		if err := sc.Err(); err != nil {
-			errCh <- err
-			close(errCh)
-		}
-	}()
-	i := &InsertsStreamWriter{
-		enc:    enc,
-		ackMap: ackMap,
-		curr:   0,
-		ackCh:  ackCh,
-		errCh:  errCh,
-		closer: &InsertsStreamCloser{req: pr, resp: res.Body},
-	}
-	c.insertsStreamWriters = append(c.insertsStreamWriters, i)
+	i := newInsertsStreamWriter(pw, res.Body, pw, &InsertsStreamCloser{req: pw, resp: res.Body}, conf)
+	c.trackWriter(i)
 	return i, nil
 }
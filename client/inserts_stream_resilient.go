@@ -0,0 +1,359 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// ResilientInsertsStreamWriter is an inserts stream which reconnects when the connection drops, replaying unacknowledged rows with at-least-once delivery
+type ResilientInsertsStreamWriter struct {
+	ctx     context.Context
+	client  Client
+	payload InsertsStreamTargetPayload
+	policy  ReconnectPolicy
+	conf    insertsStreamConfig
+
+	// stop interrupts reconnection attempts once the writer is closed
+	stop       context.Context
+	cancelStop context.CancelFunc
+
+	// writeLock serialises writes and reconnects, so that rows are written and replayed in sequence order. It is a channel so that a write waiting for a reconnect can give up when its context is done.
+	writeLock chan struct{}
+	// watchers tracks the goroutines waiting for acks or reconnecting
+	watchers sync.WaitGroup
+
+	// mu guards the fields below, and is never held while blocked on the network
+	mu sync.Mutex
+	// wtr is the writer for the current connection, and gen is incremented each time it is replaced
+	wtr  *InsertsStreamWriter
+	gen  int
+	curr int64
+	// attempts counts the reconnection attempts since a row was last acknowledged, so that a connection which drops straight away doesn't reset the count
+	attempts int
+	unacked  map[int64]*resilientInsert
+	// err is set once reconnecting has failed and no further rows can be written
+	err    error
+	closed bool
+}
+
+// resilientInsert is a row which has been written but not yet acknowledged
+type resilientInsert struct {
+	ack *InsertAck
+	raw json.RawMessage
+}
+
+// ResilientInsertsStream opens an inserts stream which automatically reconnects with backoff when the connection drops, replaying any unacknowledged rows
+func (c *ksqldb) ResilientInsertsStream(ctx context.Context, payload InsertsStreamTargetPayload, policy ReconnectPolicy, options ...InsertsStreamOption) (*ResilientInsertsStreamWriter, error) {
+	conf := newInsertsStreamConfig(options...)
+	if conf.validate && conf.schema == nil {
+		schema, err := c.describeInsertSchema(ctx, payload.Target)
+		if err != nil {
+			return nil, err
+		}
+		conf.schema = schema
+	}
+	wtr, err := c.InsertsStream(ctx, payload, WithMaxInFlight(conf.maxInFlight))
+	if err != nil {
+		return nil, err
+	}
+	stop, cancelStop := context.WithCancel(ctx)
+	return &ResilientInsertsStreamWriter{
+		ctx:        ctx,
+		client:     c,
+		payload:    payload,
+		policy:     policy,
+		conf:       conf,
+		stop:       stop,
+		cancelStop: cancelStop,
+		wtr:        wtr,
+		writeLock:  make(chan struct{}, 1),
+		unacked:    make(map[int64]*resilientInsert),
+	}, nil
+}
+
+// WriteAsync encodes and writes p to the inserts stream, returning an InsertAck which resolves once the row is acknowledged, possibly after reconnecting
+func (w *ResilientInsertsStreamWriter) WriteAsync(ctx context.Context, p interface{}) (*InsertAck, error) {
+	raw, err := marshalRow(w.conf.schema, p)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.lockWrites(ctx); err != nil {
+		return nil, err
+	}
+	w.mu.Lock()
+	if err := w.writeErr(); err != nil {
+		w.mu.Unlock()
+		w.unlockWrites()
+		return nil, err
+	}
+	ins := &resilientInsert{ack: newInsertAck(w.curr, p), raw: raw}
+	wtr, gen := w.wtr, w.gen
+	w.mu.Unlock()
+
+	inner, err := wtr.WriteAsync(ctx, ins.raw)
+	if err != nil && ctx.Err() != nil {
+		// the row was never written, so it doesn't use up a sequence number
+		w.unlockWrites()
+		return nil, err
+	}
+	w.mu.Lock()
+	w.unacked[w.curr] = ins
+	w.curr++
+	w.mu.Unlock()
+	if err == nil {
+		w.startWatching(gen, ins, inner)
+	} else {
+		// the row is replayed once reconnected, which happens in the background so that the caller isn't held up
+		w.startReconnecting(gen, err)
+	}
+	w.unlockWrites()
+	return ins.ack, nil
+}
+
+// WriteJSON encodes and writes p to the inserts stream, and waits for the corresponding ack to be received
+func (w *ResilientInsertsStreamWriter) WriteJSON(ctx context.Context, p interface{}) error {
+	a, err := w.WriteAsync(ctx, p)
+	if err != nil {
+		return err
+	}
+	return a.Wait(ctx)
+}
+
+// Flush waits until every row written so far has been acknowledged, returning the first failure
+func (w *ResilientInsertsStreamWriter) Flush(ctx context.Context) error {
+	w.mu.Lock()
+	acks := make([]*InsertAck, 0, len(w.unacked))
+	for _, ins := range w.unacked {
+		acks = append(acks, ins.ack)
+	}
+	w.mu.Unlock()
+	var firstErr error
+	for _, a := range acks {
+		if err := a.Wait(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// writeErr returns the error which prevents further writes, if any. It must be called with mu held.
+func (w *ResilientInsertsStreamWriter) writeErr() error {
+	if w.closed {
+		return ErrWriterClosed
+	}
+	return w.err
+}
+
+// lockWrites acquires the write lock, giving up when ctx is done
+func (w *ResilientInsertsStreamWriter) lockWrites(ctx context.Context) error {
+	select {
+	case w.writeLock <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *ResilientInsertsStreamWriter) unlockWrites() {
+	<-w.writeLock
+}
+
+// startReconnecting replaces the connection of the given generation in the background. It must be called with the write lock held.
+func (w *ResilientInsertsStreamWriter) startReconnecting(gen int, cause error) {
+	w.watchers.Add(1)
+	go func() {
+		defer w.watchers.Done()
+		w.reconnect(gen, cause)
+	}()
+}
+
+// startWatching waits for the ack of a row in the background. It must be called with the write lock held.
+func (w *ResilientInsertsStreamWriter) startWatching(gen int, ins *resilientInsert, inner *InsertAck) {
+	w.watchers.Add(1)
+	go func() {
+		defer w.watchers.Done()
+		w.watch(gen, ins, inner)
+	}()
+}
+
+// watch resolves a row once it has been acknowledged on the connection of the given generation, or reconnects if that connection drops
+func (w *ResilientInsertsStreamWriter) watch(gen int, ins *resilientInsert, inner *InsertAck) {
+	<-inner.Done()
+	err := inner.Err()
+	var insertErr *InsertError
+	switch {
+	case err == nil:
+		w.resolve(ins.ack.Seq, nil)
+	case errors.As(err, &insertErr) && insertErr.Err == nil:
+		w.resolve(ins.ack.Seq, &InsertError{
+			Seq:     ins.ack.Seq,
+			Code:    insertErr.Code,
+			Message: insertErr.Message,
+			Row:     ins.ack.row,
+		})
+	default:
+		if errors.As(err, &insertErr) {
+			// the connection failed, which is the cause of the reconnect
+			err = insertErr.Err
+		}
+		w.reconnect(gen, err)
+	}
+}
+
+// resolve completes the ack for the row with the given sequence number, unless it has already been completed by a previous connection
+func (w *ResilientInsertsStreamWriter) resolve(seq int64, err error) {
+	w.mu.Lock()
+	ins, ok := w.unacked[seq]
+	delete(w.unacked, seq)
+	if err == nil {
+		w.attempts = 0
+	}
+	w.mu.Unlock()
+	if !ok {
+		return
+	}
+	if err == nil {
+		ins.ack.resolve(nil)
+		return
+	}
+	var insertErr *InsertError
+	if !errors.As(err, &insertErr) {
+		insertErr = &InsertError{Seq: seq, Row: ins.ack.row, Err: err}
+	}
+	if w.conf.deadLetter != nil {
+		w.conf.deadLetter(insertErr)
+	}
+	ins.ack.resolve(insertErr)
+}
+
+// failUnacked resolves every row which is still awaiting an ack with err
+func (w *ResilientInsertsStreamWriter) failUnacked(err error) {
+	for _, ins := range w.pending() {
+		w.resolve(ins.ack.Seq, err)
+	}
+}
+
+// pending returns the rows awaiting an ack in sequence order
+func (w *ResilientInsertsStreamWriter) pending() []*resilientInsert {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	rows := make([]*resilientInsert, 0, len(w.unacked))
+	for _, ins := range w.unacked {
+		rows = append(rows, ins)
+	}
+	sort.Slice(rows, func(x, y int) bool { return rows[x].ack.Seq < rows[y].ack.Seq })
+	return rows
+}
+
+// reconnect replaces the connection of the given generation, replaying the unacknowledged rows. It is a no-op if the connection has already been replaced.
+func (w *ResilientInsertsStreamWriter) reconnect(gen int, cause error) {
+	if !w.isCurrent(gen) {
+		return
+	}
+	// the background context never expires, and reconnecting gives up once the writer is closed
+	_ = w.lockWrites(context.Background())
+	defer w.unlockWrites()
+	if !w.isCurrent(gen) {
+		return
+	}
+	w.mu.Lock()
+	old := w.wtr
+	w.mu.Unlock()
+	// the old connection is already dead, so closing it is best-effort
+	_ = old.Close()
+
+	w.mu.Lock()
+	attempt := w.attempts
+	w.mu.Unlock()
+	event := ReconnectEvent{Cause: cause}
+	for {
+		attempt++
+		if w.policy.MaxAttempts > 0 && attempt > w.policy.MaxAttempts {
+			w.fail(cause)
+			return
+		}
+		w.mu.Lock()
+		w.attempts = attempt
+		w.mu.Unlock()
+		if err := w.policy.backoff().wait(w.stop, attempt); err != nil {
+			w.fail(err)
+			return
+		}
+		event.Attempt = attempt
+		event.Replayed, event.Err = w.connect()
+		w.policy.notify(event)
+		if event.Err == nil {
+			return
+		}
+		cause = event.Err
+	}
+}
+
+// isCurrent reports whether the connection of the given generation is still in use
+func (w *ResilientInsertsStreamWriter) isCurrent(gen int) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return gen == w.gen && w.writeErr() == nil
+}
+
+// connect opens a new connection and replays the unacknowledged rows, returning the number of rows replayed. It must be called with the write lock held.
+func (w *ResilientInsertsStreamWriter) connect() (int, error) {
+	wtr, err := w.client.InsertsStream(w.ctx, w.payload, WithMaxInFlight(w.conf.maxInFlight))
+	if err != nil {
+		return 0, err
+	}
+	w.mu.Lock()
+	w.gen++
+	w.wtr = wtr
+	gen := w.gen
+	w.mu.Unlock()
+	rows := w.pending()
+	for _, ins := range rows {
+		inner, err := wtr.WriteAsync(w.stop, ins.raw)
+		if err != nil {
+			_ = wtr.Close()
+			return 0, err
+		}
+		w.startWatching(gen, ins, inner)
+	}
+	return len(rows), nil
+}
+
+// fail stops the writer after reconnecting has failed, failing the unacknowledged rows with err
+func (w *ResilientInsertsStreamWriter) fail(err error) {
+	w.mu.Lock()
+	if w.closed {
+		// Close fails the remaining rows
+		w.mu.Unlock()
+		return
+	}
+	w.err = err
+	w.mu.Unlock()
+	w.failUnacked(err)
+}
+
+// Close ends the inserts stream, failing any unacknowledged rows and further writes with ErrWriterClosed
+func (w *ResilientInsertsStreamWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+	// interrupt any reconnection in progress, and wait for it to give up
+	w.cancelStop()
+	_ = w.lockWrites(context.Background())
+	w.mu.Lock()
+	wtr := w.wtr
+	w.mu.Unlock()
+	w.unlockWrites()
+	err := wtr.Close()
+	// acks received before the stream ended are resolved before failing the remaining rows
+	w.watchers.Wait()
+	w.failUnacked(ErrWriterClosed)
+	return err
+}
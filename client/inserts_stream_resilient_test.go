@@ -0,0 +1,246 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vancelongwill/ksql-go/client/internal/testutils"
+)
+
+func TestResilientInsertsStream(t *testing.T) {
+	payload := InsertsStreamTargetPayload{
+		Target: "sometarget",
+	}
+	type dataRow struct {
+		K string `json:"k"`
+	}
+	// accept decodes the target and starts the response, returning the decoder and encoder for the rows and acks
+	accept := func(t *testing.T, w http.ResponseWriter, r *http.Request) (*json.Decoder, *json.Encoder) {
+		dec := json.NewDecoder(r.Body)
+		var got InsertsStreamTargetPayload
+		assert.NoError(t, dec.Decode(&got))
+		assert.Equal(t, payload, got)
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		return dec, json.NewEncoder(&flushWriter{w})
+	}
+	// ackAll acknowledges every row received until the client ends the stream
+	ackAll := func(t *testing.T, dec *json.Decoder, enc *json.Encoder, received chan<- dataRow) {
+		for seq := int64(0); ; seq++ {
+			var row dataRow
+			if err := dec.Decode(&row); err != nil {
+				return
+			}
+			received <- row
+			assert.NoError(t, enc.Encode(InsertsStreamAck{Status: "ok", Seq: seq}))
+		}
+	}
+
+	t.Run("it should replay unacknowledged rows in order when the connection drops", func(t *testing.T) {
+		var requests int32
+		received := make(chan dataRow, 10)
+		srv := testutils.Server(insertsStreamPath, func(w http.ResponseWriter, r *http.Request) {
+			dec, enc := accept(t, w, r)
+			switch atomic.AddInt32(&requests, 1) {
+			case 1:
+				var row dataRow
+				assert.NoError(t, dec.Decode(&row))
+				received <- row
+				assert.NoError(t, enc.Encode(InsertsStreamAck{Status: "ok", Seq: 0}))
+				assert.NoError(t, dec.Decode(&row))
+				received <- row
+				// returning without acking the second row drops the stream
+			default:
+				ackAll(t, dec, enc, received)
+			}
+		})
+		srv.StartTLS()
+		defer srv.Close()
+		events := make(chan ReconnectEvent, 10)
+		c := New(srv.URL, WithHTTPClient(testutils.Client()))
+		ctx := context.Background()
+		wtr, err := c.ResilientInsertsStream(ctx, payload, ReconnectPolicy{
+			Backoff: Backoff{Min: time.Millisecond},
+			OnReconnect: func(e ReconnectEvent) {
+				events <- e
+			},
+		})
+		assert.NoError(t, err)
+		defer wtr.Close()
+
+		assert.NoError(t, wtr.WriteJSON(ctx, dataRow{K: "a"}))
+		second, err := wtr.WriteAsync(ctx, dataRow{K: "b"})
+		assert.NoError(t, err)
+		assert.NoError(t, second.Wait(ctx))
+		assert.Equal(t, int64(1), second.Seq)
+		third, err := wtr.WriteAsync(ctx, dataRow{K: "c"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), third.Seq, "sequence numbers should continue across connections")
+		assert.NoError(t, third.Wait(ctx))
+
+		e := <-events
+		assert.Equal(t, 1, e.Attempt)
+		assert.Equal(t, 1, e.Replayed)
+		assert.NoError(t, e.Err)
+		assert.Error(t, e.Cause)
+		var got []dataRow
+		for i := 0; i < 4; i++ {
+			got = append(got, <-received)
+		}
+		assert.Equal(t, []dataRow{{K: "a"}, {K: "b"}, {K: "b"}, {K: "c"}}, got, "the unacknowledged row should be delivered again")
+	})
+
+	t.Run("it should not replay rows rejected by the server", func(t *testing.T) {
+		srv := testutils.Server(insertsStreamPath, func(w http.ResponseWriter, r *http.Request) {
+			dec, enc := accept(t, w, r)
+			for seq := int64(0); ; seq++ {
+				var row dataRow
+				if err := dec.Decode(&row); err != nil {
+					return
+				}
+				ack := InsertsStreamAck{Status: "ok", Seq: seq}
+				if row.K == "bad" {
+					ack = InsertsStreamAck{Status: "error", Seq: seq, ErrorCode: 40000, Message: "Invalid value"}
+				}
+				assert.NoError(t, enc.Encode(ack))
+			}
+		})
+		srv.StartTLS()
+		defer srv.Close()
+		var dead []*InsertError
+		c := New(srv.URL, WithHTTPClient(testutils.Client()))
+		ctx := context.Background()
+		wtr, err := c.ResilientInsertsStream(ctx, payload, DefaultReconnectPolicy, WithDeadLetter(func(e *InsertError) {
+			dead = append(dead, e)
+		}))
+		assert.NoError(t, err)
+		defer wtr.Close()
+		assert.NoError(t, wtr.WriteJSON(ctx, dataRow{K: "good"}))
+		err = wtr.WriteJSON(ctx, dataRow{K: "bad"})
+		assert.True(t, errors.Is(err, ErrAckUnsucessful))
+		assert.Equal(t, []*InsertError{
+			{Seq: 1, Code: 40000, Message: "Invalid value", Row: dataRow{K: "bad"}},
+		}, dead)
+	})
+
+	t.Run("it should fail unacknowledged rows once the maximum number of attempts is reached", func(t *testing.T) {
+		var requests int32
+		srv := testutils.Server(insertsStreamPath, func(w http.ResponseWriter, r *http.Request) {
+			dec, _ := accept(t, w, r)
+			if atomic.AddInt32(&requests, 1) > 1 {
+				// subsequent connections end straight away
+				return
+			}
+			var row dataRow
+			assert.NoError(t, dec.Decode(&row))
+		})
+		srv.StartTLS()
+		defer srv.Close()
+		var attempts int32
+		c := New(srv.URL, WithHTTPClient(testutils.Client()))
+		ctx := context.Background()
+		wtr, err := c.ResilientInsertsStream(ctx, payload, ReconnectPolicy{
+			MaxAttempts: 2,
+			Backoff:     Backoff{Min: time.Millisecond},
+			OnReconnect: func(e ReconnectEvent) {
+				atomic.StoreInt32(&attempts, int32(e.Attempt))
+			},
+		})
+		assert.NoError(t, err)
+		defer wtr.Close()
+		a, err := wtr.WriteAsync(ctx, dataRow{K: "a"})
+		assert.NoError(t, err)
+		timeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		err = a.Wait(timeout)
+		assert.Error(t, err)
+		assert.False(t, errors.Is(err, context.DeadlineExceeded), "the row should fail once reconnecting gives up")
+		assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+		_, err = wtr.WriteAsync(ctx, dataRow{K: "b"})
+		assert.Error(t, err, "writes should fail once reconnecting has given up")
+	})
+
+	t.Run("it should let writes give up with their context while reconnecting", func(t *testing.T) {
+		srv := testutils.Server(insertsStreamPath, func(w http.ResponseWriter, r *http.Request) {
+			dec, _ := accept(t, w, r)
+			var row dataRow
+			assert.NoError(t, dec.Decode(&row))
+		})
+		srv.StartTLS()
+		defer srv.Close()
+		events := make(chan ReconnectEvent, 100)
+		c := New(srv.URL, WithHTTPClient(testutils.Client()))
+		ctx := context.Background()
+		wtr, err := c.ResilientInsertsStream(ctx, payload, ReconnectPolicy{
+			Backoff: Backoff{Min: 10 * time.Millisecond, Max: 10 * time.Millisecond},
+			OnReconnect: func(e ReconnectEvent) {
+				events <- e
+			},
+		})
+		assert.NoError(t, err)
+		a, err := wtr.WriteAsync(ctx, dataRow{K: "a"})
+		assert.NoError(t, err)
+		// the server goes down once the stream has dropped
+		srv.Close()
+		assert.Error(t, (<-events).Err, "reconnecting should keep failing")
+
+		timeout, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+		done := make(chan error)
+		go func() { done <- wtr.WriteJSON(timeout, dataRow{K: "b"}) }()
+		select {
+		case err := <-done:
+			assert.True(t, errors.Is(err, context.DeadlineExceeded), "%v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the write should give up once its context is done")
+		}
+
+		closed := make(chan error)
+		go func() { closed <- wtr.Close() }()
+		select {
+		case <-closed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("closing should interrupt reconnecting")
+		}
+		assertLost(t, ErrWriterClosed, a.Wait(ctx))
+	})
+
+	t.Run("it should fail unacknowledged rows when closed", func(t *testing.T) {
+		srv := testutils.Server(insertsStreamPath, func(w http.ResponseWriter, r *http.Request) {
+			dec, _ := accept(t, w, r)
+			// rows are never acked
+			for dec.Decode(new(dataRow)) == nil {
+			}
+		})
+		srv.StartTLS()
+		defer srv.Close()
+		c := New(srv.URL, WithHTTPClient(testutils.Client()))
+		ctx := context.Background()
+		wtr, err := c.ResilientInsertsStream(ctx, payload, DefaultReconnectPolicy)
+		assert.NoError(t, err)
+		a, err := wtr.WriteAsync(ctx, dataRow{K: "a"})
+		assert.NoError(t, err)
+		assert.NoError(t, wtr.Close())
+		assertLost(t, ErrWriterClosed, a.Wait(ctx))
+		_, err = wtr.WriteAsync(ctx, dataRow{K: "b"})
+		assert.Equal(t, ErrWriterClosed, err)
+		assert.NoError(t, wtr.Close(), "closing twice should be a no-op")
+	})
+}
+
+// flushWriter flushes each write so that acks are sent immediately
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (f *flushWriter) Write(b []byte) (int, error) {
+	n, err := f.w.Write(b)
+	f.w.(http.Flusher).Flush()
+	return n, err
+}
@@ -123,5 +123,8 @@ func TestInsertsStream(t *testing.T) {
 			err := wtr.WriteJSON(context.Background(), &w)
 			assert.NoError(t, err)
 		}
+		assert.Len(t, c.(*ksqldb).insertsStreamWriters, 1)
+		assert.NoError(t, wtr.Close())
+		assert.Empty(t, c.(*ksqldb).insertsStreamWriters, "closed writers are no longer tracked")
 	})
 }
@@ -1,7 +1,9 @@
 package client
 
 import (
+	"crypto/tls"
 	"net/http"
+	"time"
 )
 
 // Option represents a function option for the ksqlDB client
@@ -13,3 +15,48 @@ func WithHTTPClient(client *http.Client) Option {
 		c.http = client
 	}
 }
+
+// WithConsistencyVector enables consistency tokens for pull queries run with QueryStream, so that each read is at least as fresh as the previous one.
+//
+// The vector is shared by every query made with the client, unless a query's context carries its own vector via WithSessionConsistency.
+func WithConsistencyVector(v *ConsistencyVector) Option {
+	return func(c *ksqldb) {
+		c.consistency = v
+	}
+}
+
+// WithUseNumber is an option for the ksqlDB client which decodes the numbers of query rows as json.Number rather than float64, so that BIGINT and DECIMAL values are exact
+func WithUseNumber() Option {
+	return func(c *ksqldb) {
+		c.useNumber = true
+	}
+}
+
+// WithBasicAuth is an option for the ksqlDB client which adds HTTP basic authentication credentials to every request
+func WithBasicAuth(username, password string) Option {
+	return func(c *ksqldb) {
+		c.username = username
+		c.password = password
+	}
+}
+
+// WithTLSConfig is an option for the ksqlDB client which connects to the server over HTTP2 with TLS, unless a custom HTTP client is provided
+func WithTLSConfig(config *tls.Config) Option {
+	return func(c *ksqldb) {
+		c.tlsConfig = config
+	}
+}
+
+// WithDialTimeout is an option for the ksqlDB client which limits how long connecting to the server may take, unless a custom HTTP client is provided
+func WithDialTimeout(d time.Duration) Option {
+	return func(c *ksqldb) {
+		c.dialTimeout = d
+	}
+}
+
+// WithFailover is an option for the ksqlDB client which adds servers to fail over to, in order, when the current server can't be connected to
+func WithFailover(urls ...string) Option {
+	return func(c *ksqldb) {
+		c.failover = append(c.failover, urls...)
+	}
+}
@@ -11,22 +11,48 @@ import (
 )
 
 func parseSchemaKeys(str string) []string {
-	var keys []string
-	b := strings.Builder{}
-	write := false
-	for _, r := range str {
-		if r == '`' {
-			write = !write
-			if !write {
-				keys = append(keys, b.String())
-				b.Reset()
-			}
-		} else if write {
-			b.WriteRune(r)
-		}
+	names, _ := parseSchema(str)
+	return names
+}
 
+// parseSchema splits a schema string such as "`K` STRING KEY, `S` STRUCT<`A` INTEGER>" into the top level column names and types
+func parseSchema(str string) (names []string, types []string) {
+	var (
+		depth  int
+		quoted bool
+		start  int
+	)
+	parseColumn := func(col string) {
+		col = strings.TrimSpace(col)
+		if !strings.HasPrefix(col, "`") {
+			return
+		}
+		end := strings.Index(col[1:], "`")
+		if end < 0 {
+			return
+		}
+		typ := strings.TrimSpace(col[end+2:])
+		typ = strings.TrimSuffix(typ, " PRIMARY KEY")
+		typ = strings.TrimSuffix(typ, " KEY")
+		names = append(names, col[1:end+1])
+		types = append(types, typ)
+	}
+	for i, r := range str {
+		switch {
+		case r == '`':
+			quoted = !quoted
+		case quoted:
+		case r == '<' || r == '(':
+			depth++
+		case r == '>' || r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			parseColumn(str[start:i])
+			start = i + 1
+		}
 	}
-	return keys
+	parseColumn(str[start:])
+	return names, types
 }
 
 // QueryPayload represents the JSON payload for the POST /query endpoint
@@ -83,7 +109,11 @@ func (c *ksqldb) Query(ctx context.Context, payload QueryPayload) (*QueryRows, e
 		return nil, &QueryError{statementError}
 	}
 	var resultsRaw []map[string]interface{}
-	if err := json.Unmarshal(by, &resultsRaw); err != nil {
+	dec := json.NewDecoder(bytes.NewReader(by))
+	if c.useNumber {
+		dec.UseNumber()
+	}
+	if err := dec.Decode(&resultsRaw); err != nil {
 		return nil, err
 	}
 	cols := columns{
@@ -92,7 +122,7 @@ func (c *ksqldb) Query(ctx context.Context, payload QueryPayload) (*QueryRows, e
 	if h, ok := resultsRaw[0]["header"]; ok {
 		if headerMap, ok := h.(map[string]interface{}); ok {
 			if schema, exists := headerMap["schema"]; exists {
-				cols.names = parseSchemaKeys(schema.(string))
+				cols.names, cols.types = parseSchema(schema.(string))
 				cols.count = len(cols.names)
 			}
 		}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/vancelongwill/ksql-go/lexer"
 )
 
 // QueryResultHeader is a header object which contains details of the push & pull query results
@@ -25,6 +27,8 @@ type QueryStreamPayload struct {
 	KSQL string `json:"sql"`
 	// Properties is a map of optional properties for the query
 	Properties map[string]string `json:"properties,omitempty"`
+	// RequestProperties is a map of per-request properties, such as continuation tokens for resuming push queries
+	RequestProperties map[string]interface{} `json:"requestProperties,omitempty"`
 }
 
 type queryStreamReadCloser struct {
@@ -38,6 +42,10 @@ func (q *queryStreamReadCloser) Read(b []byte) (int, error) {
 }
 
 func (q *queryStreamReadCloser) Close() error {
+	// pull queries have no ID and end by themselves, so there's nothing to close on the server
+	if q.queryID == "" {
+		return q.body.Close()
+	}
 	if err := q.client.CloseQuery(context.Background(), CloseQueryPayload{q.queryID}); err != nil {
 		return err
 	}
@@ -46,6 +54,14 @@ func (q *queryStreamReadCloser) Close() error {
 
 // QueryStream runs a streaming push & pull query
 func (c *ksqldb) QueryStream(ctx context.Context, payload QueryStreamPayload) (*QueryStreamRows, error) {
+	var consistency *ConsistencyVector
+	// consistency tokens only apply to pull queries
+	if kind, err := lexer.Classify(payload.KSQL); err == nil && kind == lexer.PullQuery {
+		consistency = c.consistencyVectorFor(ctx)
+	}
+	if consistency != nil {
+		payload = withConsistency(payload, consistency)
+	}
 	b := &bytes.Buffer{}
 	err := json.NewEncoder(b).Encode(&payload)
 	if err != nil {
@@ -64,20 +80,12 @@ func (c *ksqldb) QueryStream(ctx context.Context, payload QueryStreamPayload) (*
 	if err := dec.Decode(&header); err != nil {
 		return nil, err
 	}
-	r := &QueryStreamRows{
-		ctx: ctx,
-		body: &queryStreamReadCloser{
-			queryID: header.QueryID,
-			body:    resp.Body,
-			client:  c,
-		},
-		dec: dec,
-		columns: columns{
-			count: len(header.ColumnNames),
-			names: header.ColumnNames,
-		},
-	}
-	c.rows = append(c.rows, r)
+	r := newQueryStreamRows(ctx, &queryStreamReadCloser{
+		queryID: header.QueryID,
+		body:    resp.Body,
+		client:  c,
+	}, dec, header, consistency, c.useNumber)
+	c.trackRows(r)
 	return r, nil
 }
 
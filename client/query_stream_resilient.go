@@ -0,0 +1,225 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+const (
+	// pushV2EnabledProperty enables scalable push queries, which are required for continuation tokens
+	pushV2EnabledProperty = "ksql.query.push.v2.enabled"
+	// continuationTokensEnabledProperty instructs the server to emit continuation tokens in the query stream
+	continuationTokensEnabledProperty = "ksql.query.push.v2.continuation.tokens.enabled"
+	// continuationTokenRequestProperty is the request property used to resume a push query from a continuation token
+	continuationTokenRequestProperty = "request.ksql.query.push.continuation.token"
+)
+
+// ReconnectEvent describes an attempt to re-establish a dropped connection
+type ReconnectEvent struct {
+	// Attempt is the number of consecutive reconnection attempts, starting at 1
+	Attempt int
+	// Cause is the error which caused the connection to be dropped
+	Cause error
+	// Err is the error returned by this reconnection attempt, or nil if it succeeded
+	Err error
+	// ContinuationToken is the token the query is being resumed from, empty if the query is restarting from the beginning
+	ContinuationToken string
+	// Replayed is the number of unacknowledged rows written again after an inserts stream reconnects
+	Replayed int
+}
+
+// ReconnectPolicy configures how dropped connections are re-established
+type ReconnectPolicy struct {
+	// MaxAttempts is the maximum number of consecutive reconnection attempts. Zero means unlimited attempts.
+	MaxAttempts int
+	// Backoff is the delay between reconnection attempts. DefaultBackoff is used when Backoff.Min is zero.
+	Backoff Backoff
+	// OnReconnect is called after each reconnection attempt, successful or not
+	OnReconnect func(ReconnectEvent)
+}
+
+// DefaultReconnectPolicy retries indefinitely using the DefaultBackoff
+var DefaultReconnectPolicy = ReconnectPolicy{
+	Backoff: DefaultBackoff,
+}
+
+// backoff returns the configured backoff, falling back to DefaultBackoff so that a zero value doesn't retry in a hot loop
+func (p ReconnectPolicy) backoff() Backoff {
+	if p.Backoff.Min == 0 {
+		return DefaultBackoff
+	}
+	return p.Backoff
+}
+
+func (p ReconnectPolicy) notify(e ReconnectEvent) {
+	if p.OnReconnect != nil {
+		p.OnReconnect(e)
+	}
+}
+
+// ResilientQueryStreamRows is a push query row iterator which transparently reconnects when the underlying connection drops.
+//
+// The query resumes from the latest continuation token emitted by the server, so delivery is at-least-once: rows received after the last token may be repeated after a reconnect.
+type ResilientQueryStreamRows struct {
+	ctx     context.Context
+	client  Client
+	payload QueryStreamPayload
+	policy  ReconnectPolicy
+
+	mu     sync.Mutex
+	rows   *QueryStreamRows
+	token  string
+	closed bool
+}
+
+// ResilientQueryStream runs a scalable push query which automatically reconnects with backoff, resuming from the latest continuation token, when the connection drops
+func (c *ksqldb) ResilientQueryStream(ctx context.Context, payload QueryStreamPayload, policy ReconnectPolicy) (*ResilientQueryStreamRows, error) {
+	props := make(map[string]string, len(payload.Properties)+2)
+	for k, v := range payload.Properties {
+		props[k] = v
+	}
+	props[pushV2EnabledProperty] = "true"
+	props[continuationTokensEnabledProperty] = "true"
+	payload.Properties = props
+	rows, err := c.QueryStream(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	return &ResilientQueryStreamRows{
+		ctx:     ctx,
+		client:  c,
+		payload: payload,
+		policy:  policy,
+		rows:    rows,
+	}, nil
+}
+
+// Columns returns the column names of the query
+func (r *ResilientQueryStreamRows) Columns() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rows.Columns()
+}
+
+// ColumnTypes returns the column types of the query
+func (r *ResilientQueryStreamRows) ColumnTypes() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rows.ColumnTypes()
+}
+
+// QueryID returns the ID of the current push query, which changes each time the query is resumed
+func (r *ResilientQueryStreamRows) QueryID() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rows.QueryID()
+}
+
+// ContinuationToken returns the latest continuation token received from the server
+func (r *ResilientQueryStreamRows) ContinuationToken() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.latestToken()
+}
+
+func (r *ResilientQueryStreamRows) latestToken() string {
+	if token := r.rows.ContinuationToken(); token != "" {
+		return token
+	}
+	return r.token
+}
+
+// Next reads another row from the stream, reconnecting if the connection has dropped
+func (r *ResilientQueryStreamRows) Next(dest []interface{}) error {
+	for {
+		r.mu.Lock()
+		if r.closed {
+			r.mu.Unlock()
+			return ErrRowsClosed
+		}
+		rows := r.rows
+		r.mu.Unlock()
+
+		err := rows.Next(dest)
+		if err == nil || !r.shouldReconnect(rows, err) {
+			return err
+		}
+		if err := r.reconnect(err); err != nil {
+			return err
+		}
+	}
+}
+
+// shouldReconnect reports whether err was caused by the connection dropping, as opposed to the query ending or being cancelled
+func (r *ResilientQueryStreamRows) shouldReconnect(rows *QueryStreamRows, err error) bool {
+	if r.ctx.Err() != nil || errors.Is(err, ErrRowsClosed) {
+		return false
+	}
+	// pull queries have no query ID and end once all the results have been sent
+	if errors.Is(err, io.EOF) && rows.header.QueryID == "" {
+		return false
+	}
+	var queryErr *QueryError
+	return !errors.As(err, &queryErr)
+}
+
+func (r *ResilientQueryStreamRows) reconnect(cause error) error {
+	r.mu.Lock()
+	r.token = r.latestToken()
+	payload := r.payload
+	if r.token != "" {
+		payload.RequestProperties = make(map[string]interface{}, len(r.payload.RequestProperties)+1)
+		for k, v := range r.payload.RequestProperties {
+			payload.RequestProperties[k] = v
+		}
+		payload.RequestProperties[continuationTokenRequestProperty] = r.token
+	}
+	event := ReconnectEvent{Cause: cause, ContinuationToken: r.token}
+	r.mu.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		if err := r.policy.backoff().wait(r.ctx, attempt); err != nil {
+			return err
+		}
+		rows, err := r.client.QueryStream(r.ctx, payload)
+		event.Attempt = attempt
+		event.Err = err
+		r.policy.notify(event)
+		if err == nil {
+			r.mu.Lock()
+			if r.closed {
+				r.mu.Unlock()
+				return rows.Close()
+			}
+			old := r.rows
+			r.rows = rows
+			r.mu.Unlock()
+			// the old query is already dead, so closing it is best-effort, and done without the lock as it makes a request
+			_ = old.Close()
+			return nil
+		}
+		if r.policy.MaxAttempts > 0 && attempt >= r.policy.MaxAttempts {
+			return err
+		}
+	}
+}
+
+// Close terminates the push query
+func (r *ResilientQueryStreamRows) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	rows := r.rows
+	r.mu.Unlock()
+	return rows.Close()
+}
+
+var (
+	_ Rows        = &ResilientQueryStreamRows{}
+	_ ColumnTyper = &ResilientQueryStreamRows{}
+)
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vancelongwill/ksql-go/client/internal/testutils"
+)
+
+func TestBackoff(t *testing.T) {
+	b := Backoff{Min: time.Second, Max: 5 * time.Second, Factor: 2}
+	assert.Equal(t, time.Second, b.Duration(0))
+	assert.Equal(t, time.Second, b.Duration(1))
+	assert.Equal(t, 2*time.Second, b.Duration(2))
+	assert.Equal(t, 4*time.Second, b.Duration(3))
+	assert.Equal(t, 5*time.Second, b.Duration(4), "it should be capped at the max")
+
+	b.Jitter = true
+	for i := 0; i < 100; i++ {
+		d := b.Duration(10)
+		assert.True(t, d >= 5*time.Second/2 && d <= 5*time.Second, "the jittered delay %s should be capped at the max", d)
+	}
+}
+
+func TestReconnectPolicyBackoff(t *testing.T) {
+	t.Run("it should fall back to the default backoff when the minimum is zero", func(t *testing.T) {
+		assert.Equal(t, DefaultBackoff, ReconnectPolicy{}.backoff())
+		assert.Equal(t, DefaultBackoff, ReconnectPolicy{Backoff: Backoff{Max: time.Second}}.backoff())
+	})
+	t.Run("it should use the configured backoff", func(t *testing.T) {
+		b := Backoff{Min: time.Millisecond}
+		assert.Equal(t, b, ReconnectPolicy{Backoff: b}.backoff())
+	})
+}
+
+func TestResilientQueryStream(t *testing.T) {
+	payload := QueryStreamPayload{
+		KSQL: "SELECT * FROM s1 EMIT CHANGES;",
+	}
+	header := QueryResultHeader{
+		QueryID:     "someid",
+		ColumnNames: []string{"K", "V1"},
+		ColumnTypes: []string{"STRING", "INTEGER"},
+	}
+	t.Run("it should resume from the latest continuation token when the connection drops", func(t *testing.T) {
+		var requests int32
+		srv := testutils.Server(queryStreamPath, func(w http.ResponseWriter, r *http.Request) {
+			var got QueryStreamPayload
+			err := json.NewDecoder(r.Body).Decode(&got)
+			assert.NoError(t, err)
+			assert.Equal(t, "true", got.Properties[pushV2EnabledProperty])
+			assert.Equal(t, "true", got.Properties[continuationTokensEnabledProperty])
+			enc := json.NewEncoder(w)
+			assert.NoError(t, enc.Encode(&header))
+			switch atomic.AddInt32(&requests, 1) {
+			case 1:
+				assert.Nil(t, got.RequestProperties)
+				assert.NoError(t, enc.Encode([]interface{}{"a", 1}))
+				assert.NoError(t, enc.Encode(map[string]string{"continuationToken": "token1"}))
+				// returning drops the stream
+			default:
+				assert.Equal(t, "token1", got.RequestProperties[continuationTokenRequestProperty])
+				assert.NoError(t, enc.Encode([]interface{}{"b", 2}))
+				w.(http.Flusher).Flush()
+				<-r.Context().Done()
+			}
+		})
+		srv.StartTLS()
+		defer srv.Close()
+		var events []ReconnectEvent
+		c := New(srv.URL, WithHTTPClient(testutils.Client()))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		rows, err := c.ResilientQueryStream(ctx, payload, ReconnectPolicy{
+			Backoff: Backoff{Min: time.Millisecond},
+			OnReconnect: func(e ReconnectEvent) {
+				events = append(events, e)
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, header.ColumnNames, rows.Columns())
+
+		dest := make([]interface{}, 2)
+		assert.NoError(t, rows.Next(dest))
+		assert.Equal(t, []interface{}{"a", float64(1)}, dest)
+		assert.NoError(t, rows.Next(dest))
+		assert.Equal(t, []interface{}{"b", float64(2)}, dest)
+		assert.Equal(t, "token1", rows.ContinuationToken())
+		client := c.(*ksqldb)
+		client.mu.Lock()
+		assert.Len(t, client.rows, 1, "the rows of the dropped connection are no longer tracked")
+		client.mu.Unlock()
+
+		assert.Len(t, events, 1)
+		assert.Equal(t, 1, events[0].Attempt)
+		assert.Equal(t, "token1", events[0].ContinuationToken)
+		assert.NoError(t, events[0].Err)
+		assert.Error(t, events[0].Cause)
+
+		cancel()
+		assert.Error(t, rows.Next(dest))
+	})
+	t.Run("it should give up after the maximum number of attempts", func(t *testing.T) {
+		var requests int32
+		srv := testutils.Server(queryStreamPath, func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&requests, 1) > 1 {
+				// an empty response has no header
+				return
+			}
+			assert.NoError(t, json.NewEncoder(w).Encode(&header))
+		})
+		srv.StartTLS()
+		defer srv.Close()
+		var events []ReconnectEvent
+		c := New(srv.URL, WithHTTPClient(testutils.Client()))
+		rows, err := c.ResilientQueryStream(context.Background(), payload, ReconnectPolicy{
+			MaxAttempts: 2,
+			Backoff:     Backoff{Min: time.Millisecond},
+			OnReconnect: func(e ReconnectEvent) {
+				events = append(events, e)
+			},
+		})
+		assert.NoError(t, err)
+		err = rows.Next(make([]interface{}, 2))
+		assert.Error(t, err)
+		assert.Len(t, events, 2)
+		assert.EqualValues(t, 3, atomic.LoadInt32(&requests))
+	})
+	t.Run("it should not reconnect once closed", func(t *testing.T) {
+		srv := testutils.Server(queryStreamPath, func(w http.ResponseWriter, r *http.Request) {
+			assert.NoError(t, json.NewEncoder(w).Encode(&header))
+		})
+		srv.StartTLS()
+		defer srv.Close()
+		c := New(srv.URL, WithHTTPClient(testutils.Client()))
+		rows, err := c.ResilientQueryStream(context.Background(), payload, DefaultReconnectPolicy)
+		assert.NoError(t, err)
+		assert.NoError(t, rows.Close())
+		assert.Equal(t, ErrRowsClosed, rows.Next(make([]interface{}, 2)))
+	})
+}
@@ -31,9 +31,14 @@ func TestQueryStream(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, len(result.ColumnNames), got.columns.count)
 		assert.Equal(t, result.ColumnNames, got.columns.names)
+		assert.Equal(t, result.ColumnTypes, got.ColumnTypes())
+		assert.Empty(t, got.QueryID())
+		assert.False(t, got.IsPushQuery(), "queries without an ID are pull queries")
 		assert.NotNil(t, got)
 		ksqldb := c.(*ksqldb)
 		assert.Len(t, ksqldb.rows, 1)
+		assert.NoError(t, got.Close())
+		assert.Empty(t, ksqldb.rows, "closed rows are no longer tracked")
 	})
 	t.Run("it should stream rows", func(t *testing.T) {
 		payload := QueryStreamPayload{
@@ -157,4 +162,39 @@ func TestQueryStream(t *testing.T) {
 		err = got.Next(dest)
 		assert.Equal(t, ErrRowsClosed, err)
 	})
+
+	t.Run("when the stream contains continuation tokens and errors", func(t *testing.T) {
+		payload := QueryStreamPayload{
+			KSQL: "SELECT * FROM s1 EMIT CHANGES;",
+		}
+		header := QueryResultHeader{
+			QueryID:     "someid",
+			ColumnNames: []string{"a"},
+			ColumnTypes: []string{"STRING"},
+		}
+		results := []interface{}{
+			header,
+			[]interface{}{"first"},
+			map[string]interface{}{"continuationToken": "sometoken"},
+			[]interface{}{"second"},
+			map[string]interface{}{"@type": "generic_error", "error_code": 50000, "message": "something went wrong"},
+		}
+		srv := testutils.Server(
+			queryStreamPath, testutils.StreamingHandler(t, &payload, results...),
+		)
+		srv.StartTLS()
+		defer srv.Close()
+		c := New(srv.URL, WithHTTPClient(testutils.Client()))
+		got, err := c.QueryStream(context.Background(), payload)
+		assert.NoError(t, err)
+		dest := make([]interface{}, 1)
+		assert.NoError(t, got.Next(dest))
+		assert.Equal(t, results[1], dest)
+		assert.Empty(t, got.ContinuationToken())
+		assert.NoError(t, got.Next(dest))
+		assert.Equal(t, results[3], dest)
+		assert.Equal(t, "sometoken", got.ContinuationToken())
+		err = got.Next(dest)
+		assert.EqualError(t, err, "something went wrong")
+	})
 }
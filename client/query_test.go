@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -38,6 +39,12 @@ func TestParseSchemaKeys(t *testing.T) {
 	}
 }
 
+func TestParseSchema(t *testing.T) {
+	names, types := parseSchema("`K` STRING KEY, `S` STRUCT<`A` INTEGER, `B` ARRAY<STRING>>, `D` DECIMAL(10, 2), `M` MAP<STRING, BIGINT>")
+	assert.Equal(t, []string{"K", "S", "D", "M"}, names)
+	assert.Equal(t, []string{"STRING", "STRUCT<`A` INTEGER, `B` ARRAY<STRING>>", "DECIMAL(10, 2)", "MAP<STRING, BIGINT>"}, types)
+}
+
 func TestQueryError(t *testing.T) {
 	t.Run("given a result with no message", func(t *testing.T) {
 		err := &QueryError{map[string]interface{}{"key": "value"}}
@@ -127,4 +134,22 @@ func TestQuery(t *testing.T) {
 		assert.Equal(t, []string{"id"}, got.columns.names)
 		assert.Equal(t, got.res, results[1:])
 	})
+	t.Run("when the client decodes numbers as json.Number", func(t *testing.T) {
+		payload := QueryPayload{KSQL: "SELECT * FROM pageviews;"}
+		results := []map[string]interface{}{
+			{"header": map[string]interface{}{"schema": "`id` BIGINT"}},
+			{"row": map[string]interface{}{"columns": []interface{}{json.Number("9007199254740993")}}},
+		}
+		srv := testutils.Server(
+			queryPath, testutils.Handler(t, &payload, &results),
+		)
+		srv.StartTLS()
+		defer srv.Close()
+		c := New(srv.URL, WithHTTPClient(testutils.Client()), WithUseNumber())
+		got, err := c.Query(context.Background(), payload)
+		assert.NoError(t, err)
+		dest := make([]interface{}, 1)
+		assert.NoError(t, got.Next(dest))
+		assert.Equal(t, []interface{}{json.Number("9007199254740993")}, dest)
+	})
 }
@@ -1,10 +1,13 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"sync"
 )
 
 var (
@@ -21,6 +24,12 @@ type Rows interface {
 	Next(dest []interface{}) error
 }
 
+// ColumnTyper is implemented by rows which know the ksqlDB types of their columns
+type ColumnTyper interface {
+	// ColumnTypes returns the column types (e.g. 'BIGINT', 'STRING', 'BOOLEAN')
+	ColumnTypes() []string
+}
+
 // QueryRows is a row iterator for static queries
 type QueryRows struct {
 	res    []map[string]interface{}
@@ -70,45 +79,249 @@ func (q *QueryRows) Close() error {
 	return nil
 }
 
-// QueryStreamRows implements the standard libs Rows interface for reading DB rows
+// queryStreamBufferSize is the number of decoded rows which are read ahead of the consumer
+const queryStreamBufferSize = 32
+
+// QueryStreamRows implements the standard libs Rows interface for reading DB rows.
+//
+// A single background goroutine decodes the stream into a bounded buffer, so reading ahead is limited and a slow consumer applies backpressure to the server.
 type QueryStreamRows struct {
-	closed bool
 	ctx    context.Context
 	body   io.Closer
 	dec    *json.Decoder
+	header QueryResultHeader
 	columns
+
+	items chan streamItem
+	free  chan []interface{}
+	done  chan struct{}
+	// raw is the buffer each stream value is read into by the reader goroutine, reused for every value
+	raw json.RawMessage
+	// rowDec decodes rows from raw via rowReader, keeping numbers as json.Number. It is nil unless the client uses WithUseNumber.
+	rowDec    *json.Decoder
+	rowReader *bytes.Reader
+	// err is the terminal error returned by every subsequent call to Next
+	err error
+
+	// release is called once the rows are closed, to stop the client tracking them
+	release func()
+
+	mu                sync.Mutex
+	closed            bool
+	continuationToken string
+	consistency       *ConsistencyVector
 }
 
-func (r *QueryStreamRows) next(dest []interface{}) error {
-	if r.closed {
-		return ErrRowsClosed
+// streamItem is a row, token or error read from the stream, in the order in which it was received
+type streamItem struct {
+	values            []interface{}
+	continuationToken string
+	err               error
+}
+
+func newQueryStreamRows(ctx context.Context, body io.Closer, dec *json.Decoder, header QueryResultHeader, consistency *ConsistencyVector, useNumber bool) *QueryStreamRows {
+	r := &QueryStreamRows{
+		ctx:    ctx,
+		body:   body,
+		dec:    dec,
+		header: header,
+		columns: columns{
+			count: len(header.ColumnNames),
+			names: header.ColumnNames,
+			types: header.ColumnTypes,
+		},
+		items:       make(chan streamItem, queryStreamBufferSize),
+		free:        make(chan []interface{}, queryStreamBufferSize),
+		done:        make(chan struct{}),
+		consistency: consistency,
 	}
-	if err := r.dec.Decode(&dest); err != nil {
-		return err
+	if useNumber {
+		r.rowReader = bytes.NewReader(nil)
+		r.rowDec = json.NewDecoder(r.rowReader)
+		r.rowDec.UseNumber()
+	}
+	go r.read()
+	return r
+}
+
+// read decodes the stream until it ends, fails or the rows are closed
+func (r *QueryStreamRows) read() {
+	defer close(r.items)
+	for {
+		item := r.decode()
+		select {
+		case r.items <- item:
+		case <-r.done:
+			return
+		}
+		if item.err != nil {
+			return
+		}
+	}
+}
+
+// decode reads the next row or token from the stream.
+//
+// Rows are JSON arrays, whereas tokens and errors are JSON objects interleaved with the rows.
+// Each value is read into a reused buffer, and rows are decoded from it into a recycled slice to keep allocations per row to a minimum.
+func (r *QueryStreamRows) decode() streamItem {
+	for {
+		if err := r.dec.Decode(&r.raw); err != nil {
+			return streamItem{err: err}
+		}
+		switch {
+		case len(r.raw) > 0 && r.raw[0] == '[':
+			values := r.getValues()
+			if err := r.decodeRow(&values); err != nil {
+				return streamItem{err: err}
+			}
+			return streamItem{values: values}
+		case len(r.raw) > 0 && r.raw[0] == '{':
+			var msg map[string]interface{}
+			if err := json.Unmarshal(r.raw, &msg); err != nil {
+				return streamItem{err: err}
+			}
+			if item, ok := r.handleMessage(msg); ok {
+				return item
+			}
+		default:
+			return streamItem{err: fmt.Errorf("unexpected value in query stream: %s", r.raw)}
+		}
+	}
+}
+
+// decodeRow decodes the row held in raw into values
+func (r *QueryStreamRows) decodeRow(values *[]interface{}) error {
+	if r.rowDec == nil {
+		return json.Unmarshal(r.raw, values)
+	}
+	// a row is a complete array, so rowDec never reads past the end of raw and can be reused
+	r.rowReader.Reset(r.raw)
+	return r.rowDec.Decode(values)
+}
+
+// handleMessage processes an object in the stream which isn't a row, returning an item if the consumer needs to see it
+func (r *QueryStreamRows) handleMessage(msg map[string]interface{}) (streamItem, bool) {
+	if _, ok := msg["@type"]; ok {
+		return streamItem{err: &QueryError{msg}}, true
+	}
+	if _, ok := msg["message"]; ok {
+		return streamItem{err: &QueryError{msg}}, true
+	}
+	if token, ok := msg["continuationToken"].(string); ok && token != "" {
+		return streamItem{continuationToken: token}, true
+	}
+	if token, ok := msg["consistencyToken"].(string); ok && token != "" && r.consistency != nil {
+		r.consistency.Seed(token)
+	}
+	return streamItem{}, false
+}
+
+// getValues returns an empty slice for decoding a row into, reusing slices which have already been consumed
+func (r *QueryStreamRows) getValues() []interface{} {
+	select {
+	case values := <-r.free:
+		return values[:0]
+	default:
+		return make([]interface{}, 0, r.columns.count)
+	}
+}
+
+// putValues recycles a consumed row slice
+func (r *QueryStreamRows) putValues(values []interface{}) {
+	for i := range values {
+		values[i] = nil
+	}
+	select {
+	case r.free <- values:
+	default:
 	}
-	return r.columns.Validate(dest)
+}
+
+// QueryID returns the unique ID of a push query, which can be used to close it via CloseQuery. Pull queries have no ID.
+func (r *QueryStreamRows) QueryID() string {
+	return r.header.QueryID
+}
+
+// IsPushQuery reports whether the rows are the results of a push query, which streams until it is closed, rather than a pull query
+func (r *QueryStreamRows) IsPushQuery() bool {
+	return r.header.QueryID != ""
+}
+
+// ContinuationToken returns the latest continuation token emitted by a scalable push query, which can be used to resume the query after the last row returned by Next.
+//
+// Tokens are only emitted when the query is run with the ksql.query.push.v2.continuation.tokens.enabled property.
+func (r *QueryStreamRows) ContinuationToken() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.continuationToken
+}
+
+func (r *QueryStreamRows) isClosed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closed
 }
 
 // Next reads another Row from the stream
 func (r *QueryStreamRows) Next(dest []interface{}) error {
-	errChan := make(chan error)
-	go func() {
-		errChan <- r.next(dest)
-		close(errChan)
-	}()
-	select {
-	case <-r.ctx.Done():
-		return r.ctx.Err()
-	case err := <-errChan:
+	if r.isClosed() {
+		return ErrRowsClosed
+	}
+	if r.err != nil {
+		return r.err
+	}
+	if err := r.ctx.Err(); err != nil {
 		return err
 	}
+	for {
+		select {
+		case <-r.ctx.Done():
+			return r.ctx.Err()
+		case item, ok := <-r.items:
+			if !ok || r.isClosed() {
+				return ErrRowsClosed
+			}
+			if item.err != nil {
+				r.err = item.err
+				return item.err
+			}
+			if item.continuationToken != "" {
+				r.mu.Lock()
+				r.continuationToken = item.continuationToken
+				r.mu.Unlock()
+				continue
+			}
+			if err := r.columns.Validate(item.values); err != nil {
+				return err
+			}
+			if err := r.columns.Validate(dest); err != nil {
+				return err
+			}
+			copy(dest, item.values)
+			r.putValues(item.values)
+			return nil
+		}
+	}
 }
 
 // Close safely closes the response, allowing connections to be kept alive
 func (r *QueryStreamRows) Close() error {
-	if err := r.body.Close(); err != nil {
-		return err
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
 	}
 	r.closed = true
-	return nil
+	close(r.done)
+	r.mu.Unlock()
+	if r.release != nil {
+		r.release()
+	}
+	return r.body.Close()
 }
+
+var (
+	_ ColumnTyper = &QueryRows{}
+	_ ColumnTyper = &QueryStreamRows{}
+)
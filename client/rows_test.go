@@ -4,41 +4,39 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"github.com/stretchr/testify/assert"
+	"io"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestQueryStreamRows(t *testing.T) {
+	header := QueryResultHeader{
+		ColumnNames: []string{"id", "seq", "orgID", "firstName", "location"},
+	}
+	rows := [][]interface{}{
+		{
+			float64(1), "1", float64(11), "alice", "home",
+		},
+		{
+			float64(2), "2", float64(11), "bob", "home",
+		},
+		{
+			float64(3), "3", float64(11), "james", "home",
+		},
+	}
 	t.Run("when the Next method is called", func(t *testing.T) {
 		var err error
-		b := &bytes.Buffer{}
-		enc := json.NewEncoder(b)
-		rows := [][]interface{}{
-			{
-				float64(1), "1", float64(11), "alice", "home",
-			},
-			{
-				float64(2), "2", float64(11), "bob", "home",
-			},
-			{
-				float64(3), "3", float64(11), "james", "home",
-			},
-		}
-		err = enc.Encode(rows[0])
-		assert.NoError(t, err)
-		err = enc.Encode(rows[1])
-		assert.NoError(t, err)
-		d := json.NewDecoder(b)
-		r := &QueryStreamRows{
-			ctx: context.Background(),
-			dec: d,
-			columns: columns{
-				count: 5,
-				names: []string{"id", "seq", "orgID", "firstName", "location"},
-			},
-		}
+		pr, pw := io.Pipe()
+		enc := json.NewEncoder(pw)
+		go func() {
+			assert.NoError(t, enc.Encode(rows[0]))
+			assert.NoError(t, enc.Encode(rows[1]))
+		}()
+		r := newQueryStreamRows(context.Background(), pr, json.NewDecoder(pr), header, nil, false)
+		defer r.Close()
 		dest := make([]interface{}, r.columns.count)
 		err = r.Next(dest)
 		assert.NoError(t, err)
@@ -51,11 +49,122 @@ func TestQueryStreamRows(t *testing.T) {
 		go func() {
 			defer wg.Done()
 			time.Sleep(time.Millisecond * 100)
-			enc.Encode(rows[2])
+			assert.NoError(t, enc.Encode(rows[2]))
 		}()
 		wg.Wait()
 		err = r.Next(dest)
 		assert.NoError(t, err)
 		assert.Equal(t, rows[2], dest, "it should work async")
 	})
+	t.Run("when the stream ends", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		assert.NoError(t, json.NewEncoder(b).Encode(rows[0]))
+		r := newQueryStreamRows(context.Background(), &readCloser{rdr: b}, json.NewDecoder(b), header, nil, false)
+		dest := make([]interface{}, r.columns.count)
+		assert.NoError(t, r.Next(dest))
+		assert.Equal(t, io.EOF, r.Next(dest))
+		assert.Equal(t, io.EOF, r.Next(dest), "the error should be returned by every subsequent call")
+	})
+	t.Run("when the destination has the wrong number of columns", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		assert.NoError(t, json.NewEncoder(b).Encode(rows[0]))
+		r := newQueryStreamRows(context.Background(), &readCloser{rdr: b}, json.NewDecoder(b), header, nil, false)
+		assert.Equal(t, ErrColumnNumberMismatch, r.Next(make([]interface{}, 2)))
+	})
+	t.Run("when the rows are closed while the stream is blocked", func(t *testing.T) {
+		pr, _ := io.Pipe()
+		r := newQueryStreamRows(context.Background(), pr, json.NewDecoder(pr), header, nil, false)
+		errCh := make(chan error)
+		go func() {
+			errCh <- r.Next(make([]interface{}, r.columns.count))
+		}()
+		time.Sleep(10 * time.Millisecond)
+		assert.NoError(t, r.Close())
+		select {
+		case err := <-errCh:
+			assert.Equal(t, ErrRowsClosed, err)
+		case <-time.After(time.Second):
+			t.Fatal("Next did not return after the rows were closed")
+		}
+		assert.NoError(t, r.Close(), "closing twice should be a no-op")
+	})
+	t.Run("when a continuation token follows buffered rows", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		enc := json.NewEncoder(b)
+		assert.NoError(t, enc.Encode(rows[0]))
+		assert.NoError(t, enc.Encode(map[string]string{"continuationToken": "sometoken"}))
+		assert.NoError(t, enc.Encode(rows[1]))
+		r := newQueryStreamRows(context.Background(), &readCloser{rdr: b}, json.NewDecoder(b), header, nil, false)
+		dest := make([]interface{}, r.columns.count)
+		assert.NoError(t, r.Next(dest))
+		// give the reader time to read ahead
+		time.Sleep(10 * time.Millisecond)
+		assert.Empty(t, r.ContinuationToken(), "the token should only be updated once the preceding rows are consumed")
+		assert.NoError(t, r.Next(dest))
+		assert.Equal(t, "sometoken", r.ContinuationToken())
+	})
+	t.Run("when numbers are decoded as json.Number", func(t *testing.T) {
+		b := bytes.NewBufferString(`[9007199254740993,"a",1.5,{"N":9007199254740993}]` + "\n")
+		r := newQueryStreamRows(context.Background(), &readCloser{rdr: b}, json.NewDecoder(b), QueryResultHeader{
+			ColumnNames: []string{"ID", "K", "SCORE", "S"},
+		}, nil, true)
+		dest := make([]interface{}, 4)
+		assert.NoError(t, r.Next(dest))
+		assert.Equal(t, []interface{}{json.Number("9007199254740993"), "a", json.Number("1.5"), map[string]interface{}{"N": json.Number("9007199254740993")}}, dest)
+	})
+}
+
+// encodeBenchmarkRows encodes n rows of 5 columns
+func encodeBenchmarkRows(n int) *bytes.Buffer {
+	b := &bytes.Buffer{}
+	enc := json.NewEncoder(b)
+	for i := 0; i < n; i++ {
+		_ = enc.Encode([]interface{}{float64(i), "alice", "home", true, 12.5})
+	}
+	return b
+}
+
+// newBenchmarkRows creates a row iterator over n encoded rows
+func newBenchmarkRows(n int) *QueryStreamRows {
+	b := encodeBenchmarkRows(n)
+	header := QueryResultHeader{
+		ColumnNames: []string{"ID", "NAME", "LOCATION", "ACTIVE", "SCORE"},
+	}
+	return newQueryStreamRows(context.Background(), &readCloser{rdr: b}, json.NewDecoder(b), header, nil, false)
+}
+
+func BenchmarkQueryStreamRowsNext(b *testing.B) {
+	b.Run("QueryStreamRows", func(b *testing.B) {
+		r := newBenchmarkRows(b.N)
+		defer r.Close()
+		dest := make([]interface{}, r.columns.count)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := r.Next(dest); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	// baseline decodes each row into a new buffer and slice, with a goroutine per row, as Next did before rows were read by a single reader into reused buffers
+	b.Run("baseline", func(b *testing.B) {
+		dec := json.NewDecoder(encodeBenchmarkRows(b.N))
+		dest := make([]interface{}, 5)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			errCh := make(chan error)
+			go func() {
+				var raw json.RawMessage
+				if err := dec.Decode(&raw); err != nil {
+					errCh <- err
+					return
+				}
+				errCh <- json.Unmarshal(raw, &dest)
+			}()
+			if err := <-errCh; err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
 }
@@ -0,0 +1,350 @@
+package client
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vancelongwill/ksql-go/internal/tags"
+)
+
+var (
+	// ErrInvalidScanTarget is returned when the scan destination is not a non-nil pointer to a struct
+	ErrInvalidScanTarget = errors.New("scan destination must be a non-nil pointer to a struct")
+
+	timeType    = reflect.TypeOf(time.Time{})
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+	// timeLayouts are the formats ksqlDB uses to serialize TIMESTAMP, DATE and TIME values
+	timeLayouts = []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02",
+		"15:04:05.999999999",
+	}
+)
+
+// structFieldsCache caches the fields of each struct type, keyed by their upper-cased names
+var structFieldsCache sync.Map // map[reflect.Type]cachedFields
+
+type cachedFields struct {
+	fields map[string]tags.Field
+	err    error
+}
+
+// structFields returns the fields of t keyed by their upper-cased column names.
+//
+// Fields are named by their `ksql` tag, falling back to the field name, by the rules shared with the builder and ksqltypes. Untagged embedded structs are flattened and fields tagged with "-" are skipped.
+func structFields(t reflect.Type) (map[string]tags.Field, error) {
+	if cached, ok := structFieldsCache.Load(t); ok {
+		c := cached.(cachedFields)
+		return c.fields, c.err
+	}
+	list, err := tags.Fields(t)
+	fields := make(map[string]tags.Field, len(list))
+	for _, f := range list {
+		fields[strings.ToUpper(f.Name)] = f
+	}
+	structFieldsCache.Store(t, cachedFields{fields: fields, err: err})
+	return fields, err
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, except that it allocates nil embedded struct pointers
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// ScanStruct reads the next row from rows into the struct pointed to by dest.
+//
+// Columns are matched case-insensitively against the `ksql` struct tags (or field names), so a field tagged `ksql:"order_id"` receives the ORDER_ID column.
+// STRUCT, ARRAY and MAP columns are decoded recursively into nested structs, slices and maps. Columns without a matching field are ignored.
+func ScanStruct(rows Rows, dest interface{}) error {
+	cols := rows.Columns()
+	values := make([]interface{}, len(cols))
+	if err := rows.Next(values); err != nil {
+		return err
+	}
+	return scanRow(cols, values, dest)
+}
+
+func scanRow(cols []string, values []interface{}, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return ErrInvalidScanTarget
+	}
+	v = v.Elem()
+	fields, err := structFields(v.Type())
+	if err != nil {
+		return err
+	}
+	for i, col := range cols {
+		f, ok := fields[strings.ToUpper(col)]
+		if !ok {
+			continue
+		}
+		if err := scanValue(values[i], fieldByIndex(v, f.Index)); err != nil {
+			return fmt.Errorf("unable to scan column %s: %w", col, err)
+		}
+	}
+	return nil
+}
+
+// scanValue converts a JSON decoded ksqlDB value into dst
+func scanValue(src interface{}, dst reflect.Value) error {
+	if dst.CanAddr() && dst.Addr().Type().Implements(scannerType) {
+		return dst.Addr().Interface().(sql.Scanner).Scan(src)
+	}
+	if src == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	switch dst.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(dst.Type().Elem())
+		if err := scanValue(src, elem.Elem()); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+	case reflect.Interface:
+		if reflect.TypeOf(src).AssignableTo(dst.Type()) {
+			dst.Set(reflect.ValueOf(src))
+			return nil
+		}
+	case reflect.String:
+		if s, ok := src.(string); ok {
+			dst.SetString(s)
+			return nil
+		}
+	case reflect.Bool:
+		if b, ok := src.(bool); ok {
+			dst.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := src.(json.Number); ok {
+			i, err := strconv.ParseInt(n.String(), 10, 64)
+			if err != nil || dst.OverflowInt(i) {
+				return fmt.Errorf("value %v overflows %s", n, dst.Type())
+			}
+			dst.SetInt(i)
+			return nil
+		}
+		if f, ok := src.(float64); ok {
+			if f != math.Trunc(f) || dst.OverflowInt(int64(f)) {
+				return fmt.Errorf("value %v overflows %s", f, dst.Type())
+			}
+			dst.SetInt(int64(f))
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, ok := src.(json.Number); ok {
+			u, err := strconv.ParseUint(n.String(), 10, 64)
+			if err != nil || dst.OverflowUint(u) {
+				return fmt.Errorf("value %v overflows %s", n, dst.Type())
+			}
+			dst.SetUint(u)
+			return nil
+		}
+		if f, ok := src.(float64); ok {
+			if f < 0 || f != math.Trunc(f) || dst.OverflowUint(uint64(f)) {
+				return fmt.Errorf("value %v overflows %s", f, dst.Type())
+			}
+			dst.SetUint(uint64(f))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, ok := src.(json.Number); ok {
+			f, err := n.Float64()
+			if err != nil {
+				return err
+			}
+			dst.SetFloat(f)
+			return nil
+		}
+		if f, ok := src.(float64); ok {
+			dst.SetFloat(f)
+			return nil
+		}
+	case reflect.Struct:
+		if dst.Type() == timeType {
+			t, err := parseTime(src)
+			if err != nil {
+				return err
+			}
+			dst.Set(reflect.ValueOf(t))
+			return nil
+		}
+		if m, ok := src.(map[string]interface{}); ok {
+			return scanStruct(m, dst)
+		}
+	case reflect.Slice:
+		if s, ok := src.(string); ok && dst.Type().Elem().Kind() == reflect.Uint8 {
+			// BYTES are base64 encoded
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return err
+			}
+			dst.SetBytes(b)
+			return nil
+		}
+		if s, ok := src.([]interface{}); ok {
+			out := reflect.MakeSlice(dst.Type(), len(s), len(s))
+			for i, elem := range s {
+				if err := scanValue(elem, out.Index(i)); err != nil {
+					return err
+				}
+			}
+			dst.Set(out)
+			return nil
+		}
+	case reflect.Map:
+		if m, ok := src.(map[string]interface{}); ok && dst.Type().Key().Kind() == reflect.String {
+			out := reflect.MakeMapWithSize(dst.Type(), len(m))
+			for k, elem := range m {
+				val := reflect.New(dst.Type().Elem()).Elem()
+				if err := scanValue(elem, val); err != nil {
+					return err
+				}
+				out.SetMapIndex(reflect.ValueOf(k).Convert(dst.Type().Key()), val)
+			}
+			dst.Set(out)
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot convert %T to %s", src, dst.Type())
+}
+
+// scanStruct copies a STRUCT value into dst, matching field names case-insensitively
+func scanStruct(src map[string]interface{}, dst reflect.Value) error {
+	fields, err := structFields(dst.Type())
+	if err != nil {
+		return err
+	}
+	for k, val := range src {
+		f, ok := fields[strings.ToUpper(k)]
+		if !ok {
+			continue
+		}
+		if err := scanValue(val, fieldByIndex(dst, f.Index)); err != nil {
+			return fmt.Errorf("unable to scan field %s: %w", k, err)
+		}
+	}
+	return nil
+}
+
+// parseTime converts ksqlDB TIMESTAMP, DATE and TIME values, as well as epoch milliseconds (e.g. ROWTIME), to a time.Time
+func parseTime(src interface{}) (time.Time, error) {
+	switch v := src.(type) {
+	case json.Number:
+		ms, err := v.Int64()
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.UnixMilli(ms).UTC(), nil
+	case float64:
+		return time.Unix(0, int64(v)*int64(time.Millisecond)).UTC(), nil
+	case string:
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("unrecognised time format %q", v)
+	}
+	return time.Time{}, fmt.Errorf("cannot convert %T to time.Time", src)
+}
+
+// Collect reads all the remaining rows into a slice of T, which must be a struct type, and closes the rows.
+//
+// Push queries never end by themselves, so the rows should either come from a pull query or a context which will eventually be cancelled.
+func Collect[T any](rows Rows) ([]T, error) {
+	defer rows.Close()
+	var results []T
+	it := NewIter[T](rows)
+	for it.Next() {
+		results = append(results, it.Value())
+	}
+	return results, it.Err()
+}
+
+// Iter is a typed iterator which scans each row into a struct of type T
+//
+//	it := client.NewIter[Order](rows)
+//	defer it.Close()
+//	for it.Next() {
+//		order := it.Value()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type Iter[T any] struct {
+	rows  Rows
+	cols  []string
+	value T
+	err   error
+}
+
+// NewIter creates a new typed iterator for the given rows
+func NewIter[T any](rows Rows) *Iter[T] {
+	return &Iter[T]{rows: rows}
+}
+
+// Next advances the iterator to the next row, returning false when there are no more rows or an error occurred
+func (it *Iter[T]) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.cols == nil {
+		it.cols = it.rows.Columns()
+	}
+	values := make([]interface{}, len(it.cols))
+	if err := it.rows.Next(values); err != nil {
+		it.err = err
+		return false
+	}
+	var value T
+	if err := scanRow(it.cols, values, &value); err != nil {
+		it.err = err
+		return false
+	}
+	it.value = value
+	return true
+}
+
+// Value returns the current row
+func (it *Iter[T]) Value() T {
+	return it.value
+}
+
+// Err returns the error which stopped the iteration, if any. Reaching the end of the rows is not considered an error.
+func (it *Iter[T]) Err() error {
+	if errors.Is(it.err, io.EOF) {
+		return nil
+	}
+	return it.err
+}
+
+// Close closes the underlying rows
+func (it *Iter[T]) Close() error {
+	return it.rows.Close()
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type scanAddress struct {
+	Street string `ksql:"street"`
+	Number int    `ksql:"number"`
+}
+
+type scanBase struct {
+	ID int64 `ksql:"id"`
+}
+
+type scanOrder struct {
+	scanBase
+	Name     string         `ksql:"name"`
+	Total    float64        `ksql:"total"`
+	Paid     bool           `ksql:"paid"`
+	Address  *scanAddress   `ksql:"address"`
+	Tags     []string       `ksql:"tags"`
+	Counts   map[string]int `ksql:"counts"`
+	Created  time.Time      `ksql:"created"`
+	Raw      []byte         `ksql:"raw"`
+	Note     *string        `ksql:"note"`
+	Ignored  string         `ksql:"-"`
+	Untagged string
+}
+
+func newStaticRows(names []string, rows ...[]interface{}) *QueryRows {
+	res := make([]map[string]interface{}, len(rows))
+	for i, r := range rows {
+		res[i] = map[string]interface{}{
+			"row": map[string]interface{}{"columns": r},
+		}
+	}
+	return &QueryRows{
+		res:     res,
+		columns: columns{count: len(names), names: names},
+	}
+}
+
+func TestScanStruct(t *testing.T) {
+	names := []string{"ID", "NAME", "TOTAL", "PAID", "ADDRESS", "TAGS", "COUNTS", "CREATED", "RAW", "NOTE", "IGNORED", "UNTAGGED", "UNKNOWN"}
+	row := []interface{}{
+		float64(1), "alice", 9.5, true,
+		map[string]interface{}{"STREET": "High St", "NUMBER": float64(12)},
+		[]interface{}{"a", "b"},
+		map[string]interface{}{"x": float64(1)},
+		"2021-03-04T12:30:00.000",
+		"aGVsbG8=",
+		nil,
+		"should be skipped",
+		"untagged",
+		"unknown",
+	}
+	t.Run("when the destination is a struct pointer", func(t *testing.T) {
+		var got scanOrder
+		err := ScanStruct(newStaticRows(names, row), &got)
+		assert.NoError(t, err)
+		assert.Equal(t, scanOrder{
+			scanBase: scanBase{ID: 1},
+			Name:     "alice",
+			Total:    9.5,
+			Paid:     true,
+			Address:  &scanAddress{Street: "High St", Number: 12},
+			Tags:     []string{"a", "b"},
+			Counts:   map[string]int{"x": 1},
+			Created:  time.Date(2021, 3, 4, 12, 30, 0, 0, time.UTC),
+			Raw:      []byte("hello"),
+			Untagged: "untagged",
+		}, got)
+	})
+	t.Run("when the destination is not a struct pointer", func(t *testing.T) {
+		var got scanOrder
+		err := ScanStruct(newStaticRows(names, row), got)
+		assert.Equal(t, ErrInvalidScanTarget, err)
+	})
+	t.Run("when a tag has an unknown option", func(t *testing.T) {
+		var got struct {
+			ID int `ksql:"ID,unknown"`
+		}
+		err := ScanStruct(newStaticRows([]string{"ID"}, []interface{}{float64(1)}), &got)
+		assert.Error(t, err)
+	})
+	t.Run("when a value cannot be converted", func(t *testing.T) {
+		var got struct {
+			ID int `ksql:"ID"`
+		}
+		err := ScanStruct(newStaticRows([]string{"ID"}, []interface{}{1.5}), &got)
+		assert.Error(t, err)
+	})
+}
+
+func TestCollect(t *testing.T) {
+	type item struct {
+		K  string `ksql:"K"`
+		V1 int    `ksql:"V1"`
+	}
+	t.Run("with static query rows", func(t *testing.T) {
+		rows := newStaticRows([]string{"K", "V1"},
+			[]interface{}{"a", float64(1)},
+			[]interface{}{"b", float64(2)},
+		)
+		got, err := Collect[item](rows)
+		assert.NoError(t, err)
+		assert.Equal(t, []item{{"a", 1}, {"b", 2}}, got)
+		assert.True(t, rows.closed)
+	})
+	t.Run("with streamed query rows", func(t *testing.T) {
+		b := &bytes.Buffer{}
+		enc := json.NewEncoder(b)
+		assert.NoError(t, enc.Encode([]interface{}{"a", 1}))
+		assert.NoError(t, enc.Encode([]interface{}{"b", 2}))
+		rows := newQueryStreamRows(context.Background(), &readCloser{rdr: b}, json.NewDecoder(b), QueryResultHeader{
+			ColumnNames: []string{"K", "V1"},
+		}, nil, false)
+		got, err := Collect[item](rows)
+		assert.NoError(t, err)
+		assert.Equal(t, []item{{"a", 1}, {"b", 2}}, got)
+	})
+	t.Run("with numbers decoded as json.Number", func(t *testing.T) {
+		type exact struct {
+			ID    int64   `ksql:"ID"`
+			Score float64 `ksql:"SCORE"`
+		}
+		b := bytes.NewBufferString(`[9007199254740993,1.5]` + "\n")
+		rows := newQueryStreamRows(context.Background(), &readCloser{rdr: b}, json.NewDecoder(b), QueryResultHeader{
+			ColumnNames: []string{"ID", "SCORE"},
+		}, nil, true)
+		got, err := Collect[exact](rows)
+		assert.NoError(t, err)
+		assert.Equal(t, []exact{{9007199254740993, 1.5}}, got)
+	})
+}
+
+func TestIter(t *testing.T) {
+	type item struct {
+		K string `ksql:"k"`
+	}
+	t.Run("when a row fails to scan", func(t *testing.T) {
+		rows := newStaticRows([]string{"K"}, []interface{}{"a"}, []interface{}{true})
+		it := NewIter[item](rows)
+		defer it.Close()
+		assert.True(t, it.Next())
+		assert.Equal(t, item{"a"}, it.Value())
+		assert.False(t, it.Next())
+		assert.Error(t, it.Err())
+	})
+}
@@ -0,0 +1,281 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/vancelongwill/ksql-go/lexer"
+)
+
+// ErrIncompatibleScriptOptions is returned when ExecScript is given options which can't be used together
+var ErrIncompatibleScriptOptions = errors.New("incompatible script options")
+
+// StatementError is the error returned by ksqlDB when a statement can't be executed
+type StatementError struct {
+	// Type is the type of error, e.g. statement_error
+	Type string `json:"@type"`
+	// ErrorCode is the ksqlDB error code
+	ErrorCode int `json:"error_code"`
+	// Message describes the error
+	Message string `json:"message"`
+	// StatementText is the text of the statement which failed, if any
+	StatementText string `json:"statementText"`
+}
+
+func (e *StatementError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("statement failed with error code %d", e.ErrorCode)
+	}
+	return e.Message
+}
+
+// ScriptError is returned by ExecScript when a statement fails, pointing to its position in the script
+type ScriptError struct {
+	Statement
+	// Err is the reason the statement failed, usually a *StatementError
+	Err error
+}
+
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// StatementResult is the outcome of a single statement in a script
+type StatementResult struct {
+	Statement
+	// Results are the results returned by ksqlDB for the statement. SET, UNSET, DEFINE and UNDEFINE statements have no results.
+	Results []ExecResult
+	// Err is set when the statement failed
+	Err error
+}
+
+// ExecScriptOption configures how a script is executed
+type ExecScriptOption func(*execScriptConfig)
+
+type execScriptConfig struct {
+	singleRequest     bool
+	continueOnError   bool
+	streamsProperties StreamsProperties
+}
+
+// WithSingleRequest sends the whole script to ksqlDB in one request instead of a request per statement.
+//
+// ksqlDB validates every statement before running any of them, so the script either fails as a whole or runs to completion. This can't be combined with WithContinueOnError.
+func WithSingleRequest() ExecScriptOption {
+	return func(c *execScriptConfig) {
+		c.singleRequest = true
+	}
+}
+
+// WithContinueOnError keeps executing the remaining statements after a statement fails. By default the script stops at the first failure.
+func WithContinueOnError() ExecScriptOption {
+	return func(c *execScriptConfig) {
+		c.continueOnError = true
+	}
+}
+
+// WithScriptStreamsProperties sets the initial property overrides for the script, which SET and UNSET statements in the script can then change
+func WithScriptStreamsProperties(props StreamsProperties) ExecScriptOption {
+	return func(c *execScriptConfig) {
+		for k, v := range props {
+			c.streamsProperties[k] = v
+		}
+	}
+}
+
+// ExecScript splits a script into its statements, see SplitStatements, and executes them in order.
+//
+// By default each statement is sent in its own request, waiting for the previous command to complete. SET, UNSET, DEFINE and UNDEFINE statements are applied client side to the statements which follow them, as they would be by the ksqlDB CLI.
+// The returned results cover the statements which were executed, in order. If any statement fails, the first failure is also returned as a *ScriptError.
+func (c *ksqldb) ExecScript(ctx context.Context, script string, options ...ExecScriptOption) ([]StatementResult, error) {
+	conf := execScriptConfig{streamsProperties: make(StreamsProperties)}
+	for _, opt := range options {
+		opt(&conf)
+	}
+	if conf.singleRequest && conf.continueOnError {
+		return nil, fmt.Errorf("%w: a single request can't continue on error", ErrIncompatibleScriptOptions)
+	}
+	statements, err := SplitStatements(script)
+	if err != nil {
+		return nil, err
+	}
+	if conf.singleRequest {
+		return c.execScriptRequest(ctx, script, statements, conf)
+	}
+	return c.execScriptStatements(ctx, statements, conf)
+}
+
+// execScriptStatements sends each statement in its own request
+func (c *ksqldb) execScriptStatements(ctx context.Context, statements []Statement, conf execScriptConfig) ([]StatementResult, error) {
+	var (
+		results  = make([]StatementResult, 0, len(statements))
+		vars     = make(map[string]interface{})
+		seq      int64
+		firstErr error
+	)
+	for _, stmt := range statements {
+		res := StatementResult{Statement: stmt}
+		kind, name, value := parseDirective(stmt.Text)
+		switch kind {
+		case lexer.Set:
+			conf.streamsProperties[name] = value
+		case lexer.Unset:
+			delete(conf.streamsProperties, name)
+		case lexer.Define:
+			vars[name] = value
+		case lexer.Undefine:
+			delete(vars, name)
+		default:
+			payload := ExecPayload{
+				KSQL:                  stmt.Text,
+				StreamsProperties:     copyProperties(conf.streamsProperties),
+				CommandSequenceNumber: seq,
+			}
+			if len(vars) > 0 {
+				payload.SessionVariables = copyVariables(vars)
+			}
+			res.Results, res.Err = c.execStatement(ctx, payload)
+			for _, r := range res.Results {
+				if r.CommandResult != nil && r.CommandStatus.CommandSequenceNumber > seq {
+					seq = r.CommandStatus.CommandSequenceNumber
+				}
+			}
+		}
+		results = append(results, res)
+		if res.Err == nil {
+			continue
+		}
+		if firstErr == nil {
+			firstErr = &ScriptError{Statement: stmt, Err: res.Err}
+		}
+		if !conf.continueOnError || ctx.Err() != nil {
+			break
+		}
+	}
+	return results, firstErr
+}
+
+// execScriptRequest sends the whole script in one request, mapping the results back to the statements
+func (c *ksqldb) execScriptRequest(ctx context.Context, script string, statements []Statement, conf execScriptConfig) ([]StatementResult, error) {
+	payload := ExecPayload{KSQL: script}
+	if len(conf.streamsProperties) > 0 {
+		payload.StreamsProperties = conf.streamsProperties
+	}
+	execResults, err := c.execStatement(ctx, payload)
+	if err != nil {
+		// the script is rejected as a whole, so only the statement which failed is reported
+		res := StatementResult{Statement: failedStatement(script, statements, err), Err: err}
+		return []StatementResult{res}, &ScriptError{Statement: res.Statement, Err: err}
+	}
+	results := make([]StatementResult, 0, len(statements))
+	for _, stmt := range statements {
+		res := StatementResult{Statement: stmt}
+		if !hasNoResults(stmt.Text) && len(execResults) > 0 {
+			res.Results, execResults = execResults[:1], execResults[1:]
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// execStatement runs the payload, returning a *StatementError if ksqlDB rejects it
+func (c *ksqldb) execStatement(ctx context.Context, payload ExecPayload) ([]ExecResult, error) {
+	by, status, err := c.exec(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	if stmtErr := decodeStatementError(by, status); stmtErr != nil {
+		return nil, stmtErr
+	}
+	return decodeExecResults(by)
+}
+
+// decodeStatementError returns the error in the response body, if there is one
+func decodeStatementError(by []byte, status int) *StatementError {
+	var stmtErr StatementError
+	if err := json.Unmarshal(by, &stmtErr); err != nil {
+		// a list of results
+		if status >= http.StatusBadRequest {
+			return &StatementError{ErrorCode: status, Message: strings.TrimSpace(string(by))}
+		}
+		return nil
+	}
+	if status < http.StatusBadRequest && !strings.HasSuffix(stmtErr.Type, "error") {
+		return nil
+	}
+	if stmtErr.ErrorCode == 0 {
+		stmtErr.ErrorCode = status
+	}
+	return &stmtErr
+}
+
+// failedStatement finds the statement which caused the error, falling back to the whole script
+func failedStatement(script string, statements []Statement, err error) Statement {
+	var stmtErr *StatementError
+	if errors.As(err, &stmtErr) && stmtErr.StatementText != "" {
+		want := normaliseStatement(stmtErr.StatementText)
+		for _, stmt := range statements {
+			if normaliseStatement(stmt.Text) == want {
+				return stmt
+			}
+		}
+	}
+	return Statement{Text: script, Line: 1, Column: 1}
+}
+
+func normaliseStatement(s string) string {
+	return strings.Join(strings.Fields(strings.TrimSuffix(strings.TrimSpace(s), ";")), " ")
+}
+
+// parseDirective returns the kind of a SET, UNSET, DEFINE or UNDEFINE statement along with the name and value it assigns. Other statements are returned as lexer.Unknown.
+func parseDirective(statement string) (lexer.StatementKind, string, string) {
+	tokens, err := lexer.Tokenize(statement)
+	if err != nil {
+		return lexer.Unknown, "", ""
+	}
+	kind := lexer.ClassifyTokens(tokens)
+	tokens = lexer.Significant(tokens)
+	if n := len(tokens); n > 0 && tokens[n-1].Kind == lexer.Semicolon {
+		tokens = tokens[:n-1]
+	}
+	switch {
+	case (kind == lexer.Set || kind == lexer.Define) && len(tokens) == 4 && tokens[2].Text == "=" && tokens[3].Kind == lexer.String:
+		return kind, tokens[1].Value(), tokens[3].Value()
+	case (kind == lexer.Unset || kind == lexer.Undefine) && len(tokens) == 2:
+		return kind, tokens[1].Value(), ""
+	}
+	return lexer.Unknown, "", ""
+}
+
+// hasNoResults reports whether ksqlDB omits the statement from the results of a request
+func hasNoResults(statement string) bool {
+	kind, _ := lexer.Classify(statement)
+	return kind == lexer.Set || kind == lexer.Unset || kind == lexer.Define || kind == lexer.Undefine
+}
+
+func copyProperties(props StreamsProperties) StreamsProperties {
+	if len(props) == 0 {
+		return nil
+	}
+	cp := make(StreamsProperties, len(props))
+	for k, v := range props {
+		cp[k] = v
+	}
+	return cp
+}
+
+func copyVariables(vars map[string]interface{}) map[string]interface{} {
+	cp := make(map[string]interface{}, len(vars))
+	for k, v := range vars {
+		cp[k] = v
+	}
+	return cp
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"github.com/vancelongwill/ksql-go/lexer"
+)
+
+// ErrUnterminatedScript is returned when a script ends inside a string literal, quoted identifier or block comment
+var ErrUnterminatedScript = lexer.ErrUnterminated
+
+// Statement is a single statement in a script
+type Statement struct {
+	// Text is the statement including its terminating semicolon, if there was one
+	Text string
+	// Offset is the byte offset of the statement in the script
+	Offset int
+	// Line and Column are the 1-based position of the statement in the script
+	Line   int
+	Column int
+}
+
+// SplitStatements splits a script into its statements.
+//
+// Semicolons inside string literals, quoted identifiers and comments don't end a statement. Comments between statements are dropped, whereas comments within a statement are kept.
+func SplitStatements(script string) ([]Statement, error) {
+	tokens, err := lexer.Tokenize(script)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		statements []Statement
+		first      *lexer.Token
+		last       lexer.Token
+	)
+	for i, tok := range tokens {
+		if tok.IsTrivia() || (first == nil && tok.Kind == lexer.Semicolon) {
+			continue
+		}
+		if first == nil {
+			first = &tokens[i]
+		}
+		last = tok
+		if tok.Kind == lexer.Semicolon {
+			statements = append(statements, newStatement(script, *first, last))
+			first = nil
+		}
+	}
+	if first != nil {
+		statements = append(statements, newStatement(script, *first, last))
+	}
+	return statements, nil
+}
+
+// newStatement returns the statement spanning from the first to the last token
+func newStatement(script string, first, last lexer.Token) Statement {
+	return Statement{
+		Text:   script[first.Offset : last.Offset+len(last.Text)],
+		Offset: first.Offset,
+		Line:   first.Line,
+		Column: first.Column,
+	}
+}
@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vancelongwill/ksql-go/client/internal/testutils"
+)
+
+func TestSplitStatements(t *testing.T) {
+	t.Run("it should split statements and record their positions", func(t *testing.T) {
+		script := "CREATE STREAM a (x INT) WITH (kafka_topic='a;b');\n  DROP STREAM a;\nLIST STREAMS"
+		got, err := SplitStatements(script)
+		assert.NoError(t, err)
+		assert.Equal(t, []Statement{
+			{Text: "CREATE STREAM a (x INT) WITH (kafka_topic='a;b');", Offset: 0, Line: 1, Column: 1},
+			{Text: "DROP STREAM a;", Offset: 52, Line: 2, Column: 3},
+			{Text: "LIST STREAMS", Offset: 67, Line: 3, Column: 1},
+		}, got)
+	})
+	t.Run("it should ignore semicolons in literals, identifiers and comments", func(t *testing.T) {
+		script := strings.Join([]string{
+			"-- a comment; with a semicolon",
+			"/* a block;",
+			"   comment */ SELECT 'it''s; fine', `a;b`, \"c;d\" FROM s /* ; */ EMIT CHANGES;",
+			"-- trailing comment",
+		}, "\n")
+		got, err := SplitStatements(script)
+		assert.NoError(t, err)
+		assert.Equal(t, []Statement{{
+			Text:   "SELECT 'it''s; fine', `a;b`, \"c;d\" FROM s /* ; */ EMIT CHANGES;",
+			Offset: 57,
+			Line:   3,
+			Column: 15,
+		}}, got)
+	})
+	t.Run("it should skip empty statements", func(t *testing.T) {
+		got, err := SplitStatements(" ;; LIST TOPICS;;\n")
+		assert.NoError(t, err)
+		assert.Len(t, got, 1)
+		assert.Equal(t, "LIST TOPICS;", got[0].Text)
+	})
+	t.Run("it should fail when a literal isn't terminated", func(t *testing.T) {
+		for _, script := range []string{"SELECT 'abc;", "SELECT `abc;", "SELECT 1; /* abc"} {
+			_, err := SplitStatements(script)
+			assert.True(t, errors.Is(err, ErrUnterminatedScript), script)
+		}
+	})
+}
+
+// scriptServer replies to each request with the response registered for its statement, recording the payloads it receives
+type scriptServer struct {
+	t         *testing.T
+	responses map[string]interface{}
+
+	mu       sync.Mutex
+	payloads []ExecPayload
+}
+
+func (s *scriptServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var payload ExecPayload
+	assert.NoError(s.t, json.NewDecoder(r.Body).Decode(&payload))
+	s.mu.Lock()
+	s.payloads = append(s.payloads, payload)
+	s.mu.Unlock()
+	res, ok := s.responses[payload.KSQL]
+	if !ok {
+		res = []ExecResult{}
+	}
+	if stmtErr, ok := res.(StatementError); ok {
+		w.WriteHeader(http.StatusBadRequest)
+		res = stmtErr
+	}
+	assert.NoError(s.t, json.NewEncoder(w).Encode(res))
+}
+
+func commandResult(seq int64) []ExecResult {
+	return []ExecResult{{CommandResult: &CommandResult{
+		CommandStatus: CommandStatus{Status: "SUCCESS", CommandSequenceNumber: seq},
+	}}}
+}
+
+func TestExecScript(t *testing.T) {
+	script := strings.Join([]string{
+		"SET 'auto.offset.reset' = 'earliest';",
+		"DEFINE topic = 'orders';",
+		"CREATE STREAM a (x INT) WITH (kafka_topic='${topic}');",
+		"CREATE STREAM b AS SELECT * FROM a;",
+		"DROP STREAM c;",
+	}, "\n")
+	notFound := StatementError{
+		Type:          "statement_error",
+		ErrorCode:     40001,
+		Message:       "Source C does not exist.",
+		StatementText: "DROP STREAM c;",
+	}
+
+	t.Run("it should execute each statement in turn, applying SET and DEFINE client side", func(t *testing.T) {
+		s := &scriptServer{t: t, responses: map[string]interface{}{
+			"CREATE STREAM a (x INT) WITH (kafka_topic='${topic}');": commandResult(2),
+			"CREATE STREAM b AS SELECT * FROM a;":                    commandResult(3),
+		}}
+		srv := testutils.Server(execPath, s.ServeHTTP)
+		srv.StartTLS()
+		defer srv.Close()
+		c := New(srv.URL, WithHTTPClient(testutils.Client()))
+		results, err := c.ExecScript(context.Background(), strings.Join(strings.Split(script, "\n")[:4], "\n"))
+		assert.NoError(t, err)
+		assert.Len(t, results, 4)
+		assert.Empty(t, results[0].Results)
+		assert.Equal(t, commandResult(3), results[3].Results)
+		assert.Equal(t, 4, results[3].Line)
+
+		props := StreamsProperties{"auto.offset.reset": "earliest"}
+		vars := map[string]interface{}{"topic": "orders"}
+		assert.Equal(t, []ExecPayload{
+			{KSQL: "CREATE STREAM a (x INT) WITH (kafka_topic='${topic}');", StreamsProperties: props, SessionVariables: vars},
+			{KSQL: "CREATE STREAM b AS SELECT * FROM a;", StreamsProperties: props, SessionVariables: vars, CommandSequenceNumber: 2},
+		}, s.payloads)
+	})
+
+	t.Run("it should stop at the first failing statement", func(t *testing.T) {
+		s := &scriptServer{t: t, responses: map[string]interface{}{
+			"CREATE STREAM a (x INT) WITH (kafka_topic='${topic}');": notFound,
+		}}
+		srv := testutils.Server(execPath, s.ServeHTTP)
+		srv.StartTLS()
+		defer srv.Close()
+		c := New(srv.URL, WithHTTPClient(testutils.Client()))
+		results, err := c.ExecScript(context.Background(), script)
+		assert.Len(t, results, 3)
+		var scriptErr *ScriptError
+		assert.True(t, errors.As(err, &scriptErr))
+		assert.Equal(t, 3, scriptErr.Line)
+		assert.Equal(t, 1, scriptErr.Column)
+		var stmtErr *StatementError
+		assert.True(t, errors.As(err, &stmtErr))
+		assert.Equal(t, 40001, stmtErr.ErrorCode)
+		assert.Equal(t, stmtErr, results[2].Err)
+		assert.Len(t, s.payloads, 1)
+	})
+
+	t.Run("it should continue after a failing statement when asked to", func(t *testing.T) {
+		s := &scriptServer{t: t, responses: map[string]interface{}{
+			"CREATE STREAM b AS SELECT * FROM a;": notFound,
+			"DROP STREAM c;":                      notFound,
+		}}
+		srv := testutils.Server(execPath, s.ServeHTTP)
+		srv.StartTLS()
+		defer srv.Close()
+		c := New(srv.URL, WithHTTPClient(testutils.Client()))
+		results, err := c.ExecScript(context.Background(), script, WithContinueOnError())
+		assert.Len(t, results, 5)
+		var scriptErr *ScriptError
+		assert.True(t, errors.As(err, &scriptErr))
+		assert.Equal(t, 4, scriptErr.Line, "the first failure should be returned")
+		assert.Error(t, results[3].Err)
+		assert.Error(t, results[4].Err)
+		assert.Len(t, s.payloads, 3)
+	})
+
+	t.Run("it should map the results of a single request back to the statements", func(t *testing.T) {
+		s := &scriptServer{t: t, responses: map[string]interface{}{
+			script: append(commandResult(2), append(commandResult(3), commandResult(4)...)...),
+		}}
+		srv := testutils.Server(execPath, s.ServeHTTP)
+		srv.StartTLS()
+		defer srv.Close()
+		c := New(srv.URL, WithHTTPClient(testutils.Client()))
+		results, err := c.ExecScript(context.Background(), script, WithSingleRequest())
+		assert.NoError(t, err)
+		assert.Len(t, results, 5)
+		assert.Empty(t, results[0].Results)
+		assert.Empty(t, results[1].Results)
+		assert.Equal(t, commandResult(2), results[2].Results)
+		assert.Equal(t, commandResult(4), results[4].Results)
+		assert.Len(t, s.payloads, 1)
+	})
+
+	t.Run("it should point to the failing statement of a single request", func(t *testing.T) {
+		s := &scriptServer{t: t, responses: map[string]interface{}{script: notFound}}
+		srv := testutils.Server(execPath, s.ServeHTTP)
+		srv.StartTLS()
+		defer srv.Close()
+		c := New(srv.URL, WithHTTPClient(testutils.Client()))
+		results, err := c.ExecScript(context.Background(), script, WithSingleRequest())
+		var scriptErr *ScriptError
+		assert.True(t, errors.As(err, &scriptErr))
+		assert.Equal(t, 5, scriptErr.Line)
+		assert.Len(t, results, 1)
+		assert.Equal(t, "DROP STREAM c;", results[0].Text)
+	})
+
+	t.Run("it should reject incompatible options", func(t *testing.T) {
+		c := New("http://localhost")
+		_, err := c.ExecScript(context.Background(), script, WithSingleRequest(), WithContinueOnError())
+		assert.True(t, errors.Is(err, ErrIncompatibleScriptOptions))
+	})
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// DefaultSubscriptionBufferSize is the number of rows buffered by a Subscription unless configured otherwise
+const DefaultSubscriptionBufferSize = 64
+
+// SubscribeOption configures a Subscription
+type SubscribeOption func(*subscribeConfig)
+
+type subscribeConfig struct {
+	bufferSize int
+}
+
+// WithBufferSize sets the number of rows which can be buffered before the subscription stops reading from the server.
+//
+// Once the buffer is full, no more rows are read from the connection until the consumer catches up, so a slow consumer applies backpressure to the server rather than growing memory.
+func WithBufferSize(size int) SubscribeOption {
+	return func(c *subscribeConfig) {
+		if size >= 0 {
+			c.bufferSize = size
+		}
+	}
+}
+
+// Subscription delivers the rows of a push or pull query over a channel
+//
+//	sub, err := client.Subscribe(ctx, payload)
+//	if err != nil {
+//		...
+//	}
+//	defer sub.Close()
+//	for row := range sub.Rows() {
+//		...
+//	}
+//	if err := <-sub.Err(); err != nil {
+//		...
+//	}
+type Subscription struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	rows   *QueryStreamRows
+	rowCh  chan Row
+	errCh  chan error
+	done   chan struct{}
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Subscribe runs a streaming query and delivers its rows over a channel.
+//
+// The query is terminated via the /close-query endpoint when the context is done or the subscription is closed.
+func (c *ksqldb) Subscribe(ctx context.Context, payload QueryStreamPayload, options ...SubscribeOption) (*Subscription, error) {
+	conf := subscribeConfig{bufferSize: DefaultSubscriptionBufferSize}
+	for _, opt := range options {
+		opt(&conf)
+	}
+	rows, err := c.QueryStream(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	readCtx, cancel := context.WithCancel(ctx)
+	s := &Subscription{
+		ctx:    ctx,
+		cancel: cancel,
+		rows:   rows,
+		rowCh:  make(chan Row, conf.bufferSize),
+		errCh:  make(chan error, 1),
+		done:   make(chan struct{}),
+	}
+	go s.read(readCtx)
+	go func() {
+		<-readCtx.Done()
+		s.closeOnce.Do(func() {
+			s.closeErr = s.rows.Close()
+		})
+	}()
+	return s, nil
+}
+
+// read is the single reader goroutine which feeds the rows channel
+func (s *Subscription) read(ctx context.Context) {
+	defer close(s.done)
+	defer close(s.errCh)
+	defer close(s.rowCh)
+	// release the closer goroutine once the stream ends by itself
+	defer s.cancel()
+	for {
+		dest := make([]interface{}, len(s.rows.Columns()))
+		if err := s.rows.Next(dest); err != nil {
+			switch {
+			case s.ctx.Err() != nil:
+				s.errCh <- s.ctx.Err()
+			case ctx.Err() != nil, errors.Is(err, io.EOF):
+				// closed by the consumer, or a pull query finished
+			default:
+				s.errCh <- err
+			}
+			return
+		}
+		select {
+		case s.rowCh <- Row{Columns: dest}:
+		case <-ctx.Done():
+			if s.ctx.Err() != nil {
+				s.errCh <- s.ctx.Err()
+			}
+			return
+		}
+	}
+}
+
+// Rows returns the channel of rows, which is closed when the query ends, fails or the subscription is closed
+func (s *Subscription) Rows() <-chan Row {
+	return s.rowCh
+}
+
+// Err returns a channel which receives the error that ended the subscription, if any, and is closed once the subscription has ended
+func (s *Subscription) Err() <-chan error {
+	return s.errCh
+}
+
+// Handle calls handler for each row until the query ends, the context is done or the handler returns an error, which is then returned
+func (s *Subscription) Handle(handler func(Row) error) error {
+	for row := range s.rowCh {
+		if err := handler(row); err != nil {
+			_ = s.Close()
+			return err
+		}
+	}
+	return <-s.errCh
+}
+
+// QueryID returns the ID of the push query, which is empty for pull queries
+func (s *Subscription) QueryID() string {
+	return s.rows.QueryID()
+}
+
+// Columns returns the column names
+func (s *Subscription) Columns() []string {
+	return s.rows.Columns()
+}
+
+// ColumnTypes returns the column types (e.g. 'BIGINT', 'STRING', 'BOOLEAN')
+func (s *Subscription) ColumnTypes() []string {
+	return s.rows.ColumnTypes()
+}
+
+// Close terminates the query and waits for the reader to stop
+func (s *Subscription) Close() error {
+	s.cancel()
+	s.closeOnce.Do(func() {
+		s.closeErr = s.rows.Close()
+	})
+	<-s.done
+	return s.closeErr
+}
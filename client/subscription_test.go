@@ -0,0 +1,133 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vancelongwill/ksql-go/client/internal/testutils"
+)
+
+func TestSubscribe(t *testing.T) {
+	payload := QueryStreamPayload{
+		KSQL: "SELECT * FROM s1 EMIT CHANGES;",
+	}
+	header := QueryResultHeader{
+		QueryID:     "someid",
+		ColumnNames: []string{"K", "V1"},
+		ColumnTypes: []string{"STRING", "INTEGER"},
+	}
+	results := []interface{}{
+		header,
+		[]interface{}{"a", 1},
+		[]interface{}{"b", 2},
+	}
+	t.Run("it should deliver rows over the channel", func(t *testing.T) {
+		srv := testutils.Server(
+			queryStreamPath, testutils.StreamingHandler(t, &payload, results...),
+		)
+		srv.StartTLS()
+		defer srv.Close()
+		c := New(srv.URL, WithHTTPClient(testutils.Client()))
+		sub, err := c.Subscribe(context.Background(), payload, WithBufferSize(0))
+		assert.NoError(t, err)
+		defer sub.Close()
+		assert.Equal(t, header.QueryID, sub.QueryID())
+		assert.Equal(t, header.ColumnNames, sub.Columns())
+		assert.Equal(t, header.ColumnTypes, sub.ColumnTypes())
+		var got []Row
+		for row := range sub.Rows() {
+			got = append(got, row)
+		}
+		assert.NoError(t, <-sub.Err())
+		assert.Equal(t, []Row{
+			{Columns: []interface{}{"a", float64(1)}},
+			{Columns: []interface{}{"b", float64(2)}},
+		}, got)
+	})
+
+	t.Run("it should close the query when the context is done", func(t *testing.T) {
+		closed := make(chan CloseQueryPayload, 1)
+		srv := testutils.Server("/", func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case closeQueryPath:
+				var p CloseQueryPayload
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+				closed <- p
+			case queryStreamPath:
+				enc := json.NewEncoder(w)
+				assert.NoError(t, enc.Encode(&header))
+				assert.NoError(t, enc.Encode(results[1]))
+				w.(http.Flusher).Flush()
+				<-r.Context().Done()
+			}
+		})
+		srv.StartTLS()
+		defer srv.Close()
+		c := New(srv.URL, WithHTTPClient(testutils.Client()))
+		ctx, cancel := context.WithCancel(context.Background())
+		sub, err := c.Subscribe(ctx, payload)
+		assert.NoError(t, err)
+		row := <-sub.Rows()
+		assert.Equal(t, []interface{}{"a", float64(1)}, row.Columns)
+		cancel()
+		select {
+		case p := <-closed:
+			assert.Equal(t, header.QueryID, p.QueryID)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the query was not closed")
+		}
+		for range sub.Rows() {
+		}
+		assert.Equal(t, context.Canceled, <-sub.Err())
+		assert.NoError(t, sub.Close())
+	})
+
+	t.Run("it should not close a pull query on the server", func(t *testing.T) {
+		var closeRequests int32
+		srv := testutils.Server("/", func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case closeQueryPath:
+				atomic.AddInt32(&closeRequests, 1)
+			case queryStreamPath:
+				enc := json.NewEncoder(w)
+				assert.NoError(t, enc.Encode(&QueryResultHeader{ColumnNames: header.ColumnNames, ColumnTypes: header.ColumnTypes}))
+				assert.NoError(t, enc.Encode(results[1]))
+			}
+		})
+		srv.StartTLS()
+		defer srv.Close()
+		c := New(srv.URL, WithHTTPClient(testutils.Client()))
+		sub, err := c.Subscribe(context.Background(), QueryStreamPayload{KSQL: "SELECT * FROM t1 WHERE k = 'a';"})
+		assert.NoError(t, err)
+		for range sub.Rows() {
+		}
+		assert.NoError(t, <-sub.Err())
+		assert.NoError(t, sub.Close())
+		assert.Equal(t, int32(0), atomic.LoadInt32(&closeRequests))
+	})
+
+	t.Run("it should stop when the handler returns an error", func(t *testing.T) {
+		srv := testutils.Server(
+			queryStreamPath, testutils.StreamingHandler(t, &payload, results...),
+		)
+		srv.StartTLS()
+		defer srv.Close()
+		c := New(srv.URL, WithHTTPClient(testutils.Client()))
+		sub, err := c.Subscribe(context.Background(), payload)
+		assert.NoError(t, err)
+		handlerErr := errors.New("some error")
+		calls := 0
+		err = sub.Handle(func(row Row) error {
+			calls++
+			return handlerErr
+		})
+		assert.Equal(t, handlerErr, err)
+		assert.Equal(t, 1, calls)
+	})
+}
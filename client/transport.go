@@ -0,0 +1,110 @@
+package client
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// transport adds credentials to requests, and fails over to the next server when the current one can't be connected to
+type transport struct {
+	next     http.RoundTripper
+	username string
+	password string
+
+	// hosts holds the base URL followed by the failover URLs
+	hosts []*url.URL
+
+	mu     sync.Mutex
+	active int
+}
+
+// wrapTransport returns a copy of the http client which sends requests through a transport configured by the client options
+func (c *ksqldb) wrapTransport(hc *http.Client) *http.Client {
+	t := &transport{
+		next:     hc.Transport,
+		username: c.username,
+		password: c.password,
+	}
+	if t.next == nil {
+		t.next = http.DefaultTransport
+	}
+	for _, raw := range append([]string{c.baseURL}, c.failover...) {
+		u, err := url.Parse(raw)
+		if err != nil || u.Host == "" {
+			// an invalid base URL fails when the request is made
+			continue
+		}
+		t.hosts = append(t.hosts, u)
+	}
+	wrapped := *hc
+	wrapped.Transport = t
+	return &wrapped
+}
+
+// RoundTrip sends the request to the active server, trying the others in order if it can't be connected to
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(t.hosts) < 2 {
+		return t.next.RoundTrip(t.prepare(req, nil))
+	}
+	t.mu.Lock()
+	active := t.active
+	t.mu.Unlock()
+
+	var err error
+	for i := range t.hosts {
+		attempt := req
+		if i > 0 {
+			if req.Body != nil && req.Body != http.NoBody {
+				// the failed attempt closes the body, so it can only be replayed if it can be recreated
+				if req.GetBody == nil {
+					return nil, err
+				}
+				body, bodyErr := req.GetBody()
+				if bodyErr != nil {
+					return nil, err
+				}
+				attempt = req.Clone(req.Context())
+				attempt.Body = body
+			}
+		}
+		idx := (active + i) % len(t.hosts)
+		var res *http.Response
+		res, err = t.next.RoundTrip(t.prepare(attempt, t.hosts[idx]))
+		if err == nil {
+			if idx != active {
+				t.mu.Lock()
+				t.active = idx
+				t.mu.Unlock()
+			}
+			return res, nil
+		}
+		if req.Context().Err() != nil || !isDialError(err) {
+			// the request may already have been received, so it isn't safe to send it again
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// isDialError reports whether err occurred while connecting, before any of the request was sent
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// prepare returns a copy of the request with credentials, sent to the given host when it isn't nil. RoundTrippers must not modify the original request.
+func (t *transport) prepare(req *http.Request, host *url.URL) *http.Request {
+	req = req.Clone(req.Context())
+	if t.username != "" || t.password != "" {
+		req.SetBasicAuth(t.username, t.password)
+	}
+	if host != nil {
+		req.URL.Scheme = host.Scheme
+		req.URL.Host = host.Host
+		req.Host = host.Host
+	}
+	return req
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vancelongwill/ksql-go/client/internal/testutils"
+)
+
+func TestTransport(t *testing.T) {
+	t.Run("it should add basic auth credentials to requests", func(t *testing.T) {
+		srv := testutils.Server(infoPath, func(w http.ResponseWriter, r *http.Request) {
+			user, pass, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "user", user)
+			assert.Equal(t, "secret", pass)
+			json.NewEncoder(w).Encode(InfoResult{})
+		})
+		srv.StartTLS()
+		defer srv.Close()
+		c := New(srv.URL, WithHTTPClient(testutils.Client()), WithBasicAuth("user", "secret"))
+		_, err := c.Info(context.Background())
+		assert.NoError(t, err)
+	})
+
+	t.Run("it should fail over to the next server and stay there", func(t *testing.T) {
+		down := testutils.Server(execPath, func(w http.ResponseWriter, r *http.Request) {})
+		down.StartTLS()
+		down.Close()
+		var calls int32
+		payload := ExecPayload{KSQL: "LIST STREAMS;"}
+		up := testutils.Server(execPath, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			testutils.Handler(t, &payload, []ExecResult{})(w, r)
+		})
+		up.StartTLS()
+		defer up.Close()
+
+		c := New(down.URL, WithHTTPClient(testutils.Client()), WithFailover(up.URL))
+		for i := 0; i < 2; i++ {
+			_, err := c.Exec(context.Background(), payload)
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+		assert.Equal(t, 1, c.(*ksqldb).http.Transport.(*transport).active)
+	})
+
+	t.Run("it should not send the request to the next server after it failed mid-request", func(t *testing.T) {
+		var calls int32
+		broken := testutils.Server(execPath, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			panic(http.ErrAbortHandler)
+		})
+		broken.StartTLS()
+		defer broken.Close()
+		next := testutils.Server(execPath, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			json.NewEncoder(w).Encode([]ExecResult{})
+		})
+		next.StartTLS()
+		defer next.Close()
+
+		c := New(broken.URL, WithHTTPClient(testutils.Client()), WithFailover(next.URL))
+		_, err := c.Exec(context.Background(), ExecPayload{KSQL: "INSERT INTO s1 (k) VALUES ('a');"})
+		assert.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+		assert.Equal(t, 0, c.(*ksqldb).http.Transport.(*transport).active)
+	})
+
+	t.Run("it should return an error when no server can be reached", func(t *testing.T) {
+		down := testutils.Server(execPath, func(w http.ResponseWriter, r *http.Request) {})
+		down.StartTLS()
+		down.Close()
+		c := New(down.URL, WithHTTPClient(testutils.Client()), WithFailover(down.URL))
+		_, err := c.Exec(context.Background(), ExecPayload{KSQL: "LIST STREAMS;"})
+		assert.Error(t, err)
+	})
+
+	t.Run("it should use TLS when configured", func(t *testing.T) {
+		srv := testutils.Server(infoPath, func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(InfoResult{})
+		})
+		srv.StartTLS()
+		defer srv.Close()
+		c := New(srv.URL, WithTLSConfig(&tls.Config{InsecureSkipVerify: true}))
+		_, err := c.Info(context.Background())
+		assert.NoError(t, err)
+	})
+}
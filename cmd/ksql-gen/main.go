@@ -0,0 +1,66 @@
+// Command ksql-gen generates Go types from the streams and tables of a ksqlDB cluster.
+//
+// Usage:
+//
+//	ksql-gen -url http://localhost:8088 [-package ksqlschema] [-sources S1,T1,...] [-out schema.go]
+//
+// The generated code is written to stdout when -out is empty.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	ksql "github.com/vancelongwill/ksql-go/client"
+	"github.com/vancelongwill/ksql-go/generator"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "ksql-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		url     = flag.String("url", "http://localhost:8088", "the ksqlDB server URL")
+		pkg     = flag.String("package", generator.DefaultPackage, "the package name of the generated code")
+		sources = flag.String("sources", "", "comma separated streams and tables to generate types for (all by default)")
+		out     = flag.String("out", "", "the file to write the generated code to (stdout by default)")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	conf := generator.Config{Package: *pkg}
+	for _, name := range strings.Split(*sources, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			conf.Sources = append(conf.Sources, name)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	c := ksql.New(*url)
+	defer c.Close()
+	src, err := generator.Generate(ctx, c, conf)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(*out, src, 0o644)
+}
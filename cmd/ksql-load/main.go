@@ -0,0 +1,126 @@
+// Command ksql-load bulk loads a CSV or newline delimited JSON file into a ksqlDB stream.
+//
+// Usage:
+//
+//	ksql-load -url http://localhost:8088 -target s1 [-format csv|ndjson] [-map field=COLUMN,...] [-parallel 1] file
+//
+// The file is read from stdin when it is "-", in which case -format is required.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	ksql "github.com/vancelongwill/ksql-go/client"
+	"github.com/vancelongwill/ksql-go/loader"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "ksql-load:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var (
+		url         = flag.String("url", "http://localhost:8088", "the ksqlDB server URL")
+		target      = flag.String("target", "", "the stream to insert the rows into (required)")
+		format      = flag.String("format", "", "the file format, csv or ndjson (inferred from the file extension by default)")
+		columns     = flag.String("map", "", "comma separated field=COLUMN pairs renaming fields in the file, use field=- to skip a field")
+		parallelism = flag.Int("parallel", 1, "the number of inserts streams to write to concurrently")
+		maxInFlight = flag.Int("max-in-flight", ksql.DefaultMaxInFlight, "the maximum number of rows awaiting an ack on each inserts stream")
+		interval    = flag.Duration("progress", loader.DefaultProgressInterval, "how often to report progress, 0 to disable")
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] file\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *target == "" || flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	path := flag.Arg(0)
+
+	conf := loader.Config{
+		Target:           *target,
+		Format:           loader.Format(*format),
+		Parallelism:      *parallelism,
+		MaxInFlight:      *maxInFlight,
+		ProgressInterval: *interval,
+	}
+	if conf.Format == "" {
+		f, err := loader.FormatFromPath(path)
+		if err != nil {
+			return err
+		}
+		conf.Format = f
+	}
+	mapping, err := parseMapping(*columns)
+	if err != nil {
+		return err
+	}
+	conf.Columns = mapping
+	if *interval > 0 {
+		conf.Progress = func(p loader.Progress) {
+			fmt.Fprintf(os.Stderr, "read %d, acked %d, failed %d\n", p.Read, p.Acked, p.Failed)
+		}
+	}
+
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	c := ksql.New(*url)
+	defer c.Close()
+	summary, err := loader.Load(ctx, c, r, conf)
+	printSummary(summary)
+	if err != nil {
+		return err
+	}
+	if summary.Failed > 0 {
+		return fmt.Errorf("%d rows failed", summary.Failed)
+	}
+	return nil
+}
+
+// parseMapping parses comma separated field=COLUMN pairs
+func parseMapping(s string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	if s == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid column mapping %q, expected field=COLUMN", pair)
+		}
+		mapping[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return mapping, nil
+}
+
+func printSummary(s loader.Summary) {
+	fmt.Fprintf(os.Stderr, "\nloaded %d of %d rows in %s, %d failed\n", s.Acked, s.Read, s.Duration.Round(time.Millisecond), s.Failed)
+	for _, f := range s.Failures {
+		fmt.Fprintf(os.Stderr, "  line %d: %v\n", f.Line, f.Err)
+	}
+	if int64(len(s.Failures)) < s.Failed {
+		fmt.Fprintf(os.Stderr, "  ... and %d more\n", s.Failed-int64(len(s.Failures)))
+	}
+}
@@ -4,29 +4,25 @@ import (
 	"context"
 	"database/sql"
 	"log"
+
+	"github.com/vancelongwill/ksql-go/builder"
 )
 
 var (
-	createTable = `
-CREATE TABLE t1 AS
-SELECT k,
-       LATEST_BY_OFFSET(v1) AS v1,
-       LATEST_BY_OFFSET(v2) AS v2,
-       LATEST_BY_OFFSET(v3) AS v3
-FROM s1
-GROUP BY k
-EMIT CHANGES;`
-	createStream = `
-CREATE STREAM s1 (
-    k VARCHAR KEY,
-    v1 INT,
-    v2 VARCHAR,
-    v3 BOOLEAN
-) WITH (
-    kafka_topic = 's1',
-    partitions = 1,
-    value_format = 'avro'
-);`
+	createStream = builder.CreateStream("s1").
+			Key("k", "VARCHAR").
+			Column("v1", "INT").
+			Column("v2", "VARCHAR").
+			Column("v3", "BOOLEAN").
+			With(builder.Properties{KafkaTopic: "s1", Partitions: 1, ValueFormat: builder.Avro})
+	createTable = builder.CreateTable("t1").
+			AsSelect(builder.Select("k").
+				Column("LATEST_BY_OFFSET(v1)", "v1").
+				Column("LATEST_BY_OFFSET(v2)", "v2").
+				Column("LATEST_BY_OFFSET(v3)", "v3").
+				From("s1").
+				GroupBy("k").
+				EmitChanges())
 	insertData = `
 INSERT INTO s1 (
     k, v1, v2, v3
@@ -71,7 +67,7 @@ func (s *Seeder) Seed(ctx context.Context) error {
 		return err
 	}
 	log.Println("Creating stream")
-	if _, err := s.db.ExecContext(ctx, createStream); err != nil {
+	if err := s.exec(ctx, createStream); err != nil {
 		return err
 	}
 	log.Println("Inserting data")
@@ -79,12 +75,21 @@ func (s *Seeder) Seed(ctx context.Context) error {
 		return err
 	}
 	log.Println("Creating table based on stream")
-	if _, err := s.db.ExecContext(ctx, createTable); err != nil {
+	if err := s.exec(ctx, createTable); err != nil {
 		return err
 	}
 	return nil
 }
 
+func (s *Seeder) exec(ctx context.Context, stmt builder.Statement) error {
+	q, err := stmt.ToSQL()
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, q)
+	return err
+}
+
 // New returns a new seeder for the given DB
 func New(db *sql.DB) *Seeder {
 	return &Seeder{db}
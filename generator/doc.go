@@ -0,0 +1,2 @@
+// Package generator generates Go types from the streams and tables of a ksqlDB cluster
+package generator
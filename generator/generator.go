@@ -0,0 +1,243 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+
+	ksql "github.com/vancelongwill/ksql-go/client"
+)
+
+// DefaultPackage is the package name of the generated code when none is configured
+const DefaultPackage = "ksqlschema"
+
+// keyFieldType is the type of key columns in a source description
+const keyFieldType = "KEY"
+
+// ksqltypesPath is the import path of the package holding the types of DECIMAL columns
+const ksqltypesPath = "github.com/vancelongwill/ksql-go/ksqltypes"
+
+// Config configures the generated code
+type Config struct {
+	// Package is the name of the generated package, defaulting to DefaultPackage
+	Package string
+	// Sources limits the generated types to the named streams and tables, matched case-insensitively. All sources are generated when it is empty.
+	Sources []string
+}
+
+// Generate describes the streams and tables of the cluster and returns the source of a Go file with a struct for each of them
+func Generate(ctx context.Context, c ksql.Client, conf Config) ([]byte, error) {
+	sources, err := Describe(ctx, c, conf)
+	if err != nil {
+		return nil, err
+	}
+	return Render(sources, conf)
+}
+
+// Describe lists the streams and tables of the cluster and describes each of them, sorted by name
+func Describe(ctx context.Context, c ksql.Client, conf Config) ([]ksql.SourceDescription, error) {
+	wanted := make(map[string]bool, len(conf.Sources))
+	for _, name := range conf.Sources {
+		wanted[strings.ToUpper(name)] = true
+	}
+	streams, err := c.ListStreams(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list streams: %w", err)
+	}
+	tables, err := c.ListTables(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list tables: %w", err)
+	}
+	var names []string
+	for _, s := range streams.Streams {
+		names = append(names, s.Name)
+	}
+	for _, t := range tables.Tables {
+		names = append(names, t.Name)
+	}
+	sort.Strings(names)
+
+	var descriptions []ksql.SourceDescription
+	for _, name := range names {
+		if len(wanted) > 0 && !wanted[strings.ToUpper(name)] {
+			continue
+		}
+		delete(wanted, strings.ToUpper(name))
+		desc, err := c.Describe(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("unable to describe %s: %w", name, err)
+		}
+		descriptions = append(descriptions, desc.SourceDescription)
+	}
+	if len(wanted) > 0 {
+		var missing []string
+		for name := range wanted {
+			missing = append(missing, name)
+		}
+		sort.Strings(missing)
+		return nil, fmt.Errorf("unknown sources: %s", strings.Join(missing, ", "))
+	}
+	return descriptions, nil
+}
+
+// Render returns the formatted source of a Go file with a constant holding the name of each source, and a struct for its rows.
+//
+// Struct fields are tagged with `ksql`, `db` and `json` tags holding the column name, and key columns are marked with the key option of the `ksql` tag. STRUCT columns get their own types, named after the source and the column, with a numeric suffix if the name is already taken.
+func Render(sources []ksql.SourceDescription, conf Config) ([]byte, error) {
+	if conf.Package == "" {
+		conf.Package = DefaultPackage
+	}
+	r := &renderer{used: make(map[string]bool)}
+	// the sources are named first, so that the types of STRUCT columns give way to them
+	names := make([]sourceNames, len(sources))
+	for i, src := range sources {
+		names[i] = r.sourceNames(src)
+	}
+	for i, src := range sources {
+		r.source(src, names[i])
+	}
+	var b strings.Builder
+	b.WriteString("// Code generated by ksql-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", conf.Package)
+	// the standard library is imported in its own group, as goimports does
+	var imports []string
+	if r.usesTime {
+		imports = append(imports, strconv.Quote("time"))
+	}
+	if r.usesTime && r.usesDecimal {
+		imports = append(imports, "")
+	}
+	if r.usesDecimal {
+		imports = append(imports, strconv.Quote(ksqltypesPath))
+	}
+	switch len(imports) {
+	case 0:
+	case 1:
+		fmt.Fprintf(&b, "import %s\n\n", imports[0])
+	default:
+		fmt.Fprintf(&b, "import (\n%s\n)\n\n", strings.Join(imports, "\n"))
+	}
+	if len(r.consts) > 0 {
+		b.WriteString("// Names of the streams and tables\nconst (\n")
+		for _, c := range r.consts {
+			b.WriteString(c)
+		}
+		b.WriteString(")\n")
+	}
+	for _, t := range r.types {
+		b.WriteString("\n")
+		b.WriteString(t)
+	}
+	src, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return nil, fmt.Errorf("unable to format the generated code: %w", err)
+	}
+	return src, nil
+}
+
+// renderer accumulates the declarations of the generated file
+type renderer struct {
+	consts      []string
+	types       []string
+	usesTime    bool
+	usesDecimal bool
+	// used holds the type and const names declared so far, which share the package scope
+	used map[string]bool
+}
+
+// sourceNames are the Go names declared for a source
+type sourceNames struct {
+	kind      string
+	typeName  string
+	constName string
+}
+
+// unique returns name, or name with the lowest numeric suffix which hasn't been declared yet, and marks it as declared
+func (r *renderer) unique(name string) string {
+	unique := name
+	for n := 2; r.used[unique]; n++ {
+		unique = name + strconv.Itoa(n)
+	}
+	r.used[unique] = true
+	return unique
+}
+
+func (r *renderer) sourceNames(src ksql.SourceDescription) sourceNames {
+	kind := strings.ToLower(src.Type)
+	if kind == "" {
+		kind = "source"
+	}
+	typeName := r.unique(goName(src.Name))
+	return sourceNames{
+		kind:      kind,
+		typeName:  typeName,
+		constName: r.unique(typeName + goName(kind)),
+	}
+}
+
+func (r *renderer) source(src ksql.SourceDescription, names sourceNames) {
+	r.consts = append(r.consts, fmt.Sprintf("\t// %s is the name of the %s %s\n\t%s = %s\n",
+		names.constName, src.Name, names.kind, names.constName, strconv.Quote(src.Name)))
+	r.structType(names.typeName, fmt.Sprintf("is a row of the %s %s", src.Name, names.kind), src.Fields)
+}
+
+// structType adds a struct type with a field for each column or STRUCT field
+func (r *renderer) structType(name, doc string, fields []ksql.Field) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s %s\ntype %s struct {\n", name, doc, name)
+	used := make(map[string]bool)
+	for _, f := range fields {
+		// distinct columns such as A_B and A__B can have the same Go name
+		fieldName := goName(f.Name)
+		for n := 2; used[fieldName]; n++ {
+			fieldName = goName(f.Name) + strconv.Itoa(n)
+		}
+		used[fieldName] = true
+		typ := r.goType(name+fieldName, f.Schema)
+		ksqlTag := f.Name
+		if f.Type == keyFieldType {
+			ksqlTag += ",key"
+			fmt.Fprintf(&b, "\t// %s is a key column\n", fieldName)
+		}
+		fmt.Fprintf(&b, "\t%s %s `ksql:%s db:%s json:%s`\n", fieldName, typ, strconv.Quote(ksqlTag), strconv.Quote(f.Name), strconv.Quote(f.Name))
+	}
+	b.WriteString("}\n")
+	r.types = append(r.types, b.String())
+}
+
+// goType returns the Go type of a column, adding a named type for STRUCT columns
+func (r *renderer) goType(name string, schema ksql.Schema) string {
+	switch strings.ToUpper(schema.Type) {
+	case "STRING", "VARCHAR":
+		return "string"
+	case "BOOLEAN":
+		return "bool"
+	case "INTEGER", "INT":
+		return "int32"
+	case "BIGINT":
+		return "int64"
+	case "DOUBLE":
+		return "float64"
+	case "DECIMAL":
+		r.usesDecimal = true
+		return "ksqltypes.Decimal"
+	case "BYTES":
+		return "[]byte"
+	case "TIMESTAMP", "DATE", "TIME":
+		r.usesTime = true
+		return "time.Time"
+	case "ARRAY":
+		return "[]" + r.goType(name+"Element", schema.Member())
+	case "MAP":
+		return "map[string]" + r.goType(name+"Value", schema.Member())
+	case "STRUCT":
+		// a STRUCT column can have the same name as another source or STRUCT type
+		name = r.unique(name)
+		r.structType(name, "is a STRUCT", schema.Fields)
+		return name
+	}
+	return "interface{}"
+}
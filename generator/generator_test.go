@@ -0,0 +1,171 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	ksql "github.com/vancelongwill/ksql-go/client"
+	"github.com/vancelongwill/ksql-go/stdlib/mocks"
+	"golang.org/x/net/http2"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// newRecordedServer replays the responses recorded in testdata/cluster.json, keyed by statement
+func newRecordedServer(t *testing.T) *httptest.Server {
+	by, err := os.ReadFile(filepath.Join("testdata", "cluster.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recorded map[string]json.RawMessage
+	if err := json.Unmarshal(by, &recorded); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload ksql.ExecPayload
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		res, ok := recorded[payload.KSQL]
+		if !ok {
+			t.Errorf("unexpected statement %q", payload.KSQL)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write(res)
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	return srv
+}
+
+func recordedClient(srv *httptest.Server) ksql.Client {
+	tr := &http.Transport{}
+	if err := http2.ConfigureTransport(tr); err != nil {
+		panic(err)
+	}
+	tr.TLSClientConfig.InsecureSkipVerify = true
+	return ksql.New(srv.URL, ksql.WithHTTPClient(&http.Client{Transport: tr}))
+}
+
+// assertGolden compares got with the golden file in testdata, updating it when the -update flag is set
+func assertGolden(t *testing.T, name string, got []byte) {
+	golden := filepath.Join("testdata", name)
+	if *update {
+		assert.NoError(t, os.WriteFile(golden, got, 0o644))
+	}
+	want, err := os.ReadFile(golden)
+	assert.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func TestGenerate(t *testing.T) {
+	t.Run("it should generate types for every stream and table", func(t *testing.T) {
+		srv := newRecordedServer(t)
+		defer srv.Close()
+		got, err := Generate(context.Background(), recordedClient(srv), Config{})
+		assert.NoError(t, err)
+
+		assertGolden(t, "schema.golden", got)
+	})
+
+	t.Run("it should only describe the selected sources", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		c := mocks.NewMockClient(ctrl)
+		ctx := context.Background()
+		c.EXPECT().ListStreams(ctx).Return(ksql.ListStreamsResult{Streams: []ksql.Stream{{Name: "ORDERS"}}}, nil)
+		c.EXPECT().ListTables(ctx).Return(ksql.ListTablesResult{Tables: []ksql.Table{{Name: "USERS"}}}, nil)
+		c.EXPECT().Describe(ctx, "USERS").Return(ksql.DescribeResult{SourceDescription: ksql.SourceDescription{
+			Name:   "USERS",
+			Type:   "TABLE",
+			Fields: []ksql.Field{{Name: "ID", Schema: ksql.Schema{Type: "STRING"}, Type: "KEY"}},
+		}}, nil)
+		got, err := Describe(ctx, c, Config{Sources: []string{"users"}})
+		assert.NoError(t, err)
+		assert.Len(t, got, 1)
+		assert.Equal(t, "USERS", got[0].Name)
+	})
+
+	t.Run("it should fail for unknown sources", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		c := mocks.NewMockClient(ctrl)
+		ctx := context.Background()
+		c.EXPECT().ListStreams(ctx).Return(ksql.ListStreamsResult{}, nil)
+		c.EXPECT().ListTables(ctx).Return(ksql.ListTablesResult{}, nil)
+		_, err := Describe(ctx, c, Config{Sources: []string{"missing"}})
+		assert.EqualError(t, err, "unknown sources: MISSING")
+	})
+
+	t.Run("it should return errors from the client", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		c := mocks.NewMockClient(ctrl)
+		ctx := context.Background()
+		errList := errors.New("connection refused")
+		c.EXPECT().ListStreams(ctx).Return(ksql.ListStreamsResult{}, errList)
+		_, err := Generate(ctx, c, Config{})
+		assert.True(t, errors.Is(err, errList))
+	})
+}
+
+func TestRender(t *testing.T) {
+	t.Run("it should deduplicate field names and fall back to interface{}", func(t *testing.T) {
+		got, err := Render([]ksql.SourceDescription{{
+			Name: "s1",
+			Type: "STREAM",
+			Fields: []ksql.Field{
+				{Name: "A_B", Schema: ksql.Schema{Type: "STRING"}},
+				{Name: "A__B", Schema: ksql.Schema{Type: "STRING"}},
+				{Name: "1ST", Schema: ksql.Schema{Type: "GEOMETRY"}},
+			},
+		}}, Config{Package: "p"})
+		assert.NoError(t, err)
+		assert.Contains(t, string(got), "package p\n")
+		assert.Contains(t, string(got), "S1Stream = \"s1\"")
+		assert.Contains(t, string(got), "AB   string")
+		assert.Contains(t, string(got), "AB2  string")
+		assert.Contains(t, string(got), "X1st interface{}")
+	})
+
+	t.Run("it should deduplicate type and const names", func(t *testing.T) {
+		item := ksql.Schema{Type: "STRUCT", Fields: []ksql.Field{{Name: "SKU", Schema: ksql.Schema{Type: "STRING"}}}}
+		got, err := Render([]ksql.SourceDescription{
+			{
+				Name: "ORDERS",
+				Type: "STREAM",
+				Fields: []ksql.Field{
+					{Name: "ITEM", Schema: item},
+					{Name: "STREAM", Schema: item},
+				},
+			},
+			{
+				Name:   "ORDERS_ITEM",
+				Type:   "STREAM",
+				Fields: []ksql.Field{{Name: "ID", Schema: ksql.Schema{Type: "STRING"}, Type: "KEY"}},
+			},
+		}, Config{})
+		assert.NoError(t, err)
+		assertGolden(t, "collisions.golden", got)
+	})
+}
+
+func TestGoName(t *testing.T) {
+	for in, want := range map[string]string{
+		"ORDER_ID":    "OrderID",
+		"created_at":  "CreatedAt",
+		"API_URL":     "APIURL",
+		"Name":        "Name",
+		"2FA_ENABLED": "X2faEnabled",
+	} {
+		assert.Equal(t, want, goName(in), in)
+	}
+}
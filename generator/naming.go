@@ -0,0 +1,36 @@
+package generator
+
+import (
+	"strings"
+	"unicode"
+)
+
+// initialisms are the words kept upper case in Go names, following the Go naming conventions
+var initialisms = map[string]bool{
+	"API": true, "DB": true, "HTTP": true, "ID": true, "IP": true, "JSON": true,
+	"SQL": true, "TTL": true, "UID": true, "URI": true, "URL": true, "UUID": true,
+}
+
+// goName converts a ksqlDB name, e.g. ORDER_ID, to an exported Go name, e.g. OrderID
+func goName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, w := range words {
+		upper := strings.ToUpper(w)
+		if initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(strings.ToLower(w))
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	s := b.String()
+	if s == "" || !unicode.IsLetter([]rune(s)[0]) {
+		// Go identifiers can't start with a digit
+		s = "X" + s
+	}
+	return s
+}
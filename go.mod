@@ -1,6 +1,6 @@
 module github.com/vancelongwill/ksql-go
 
-go 1.15
+go 1.18
 
 require (
 	github.com/golang/mock v1.4.4
@@ -8,3 +8,10 @@ require (
 	golang.org/x/net v0.0.0-20201031054903-ff519b6c9102
 	golang.org/x/sync v0.0.0-20201020160332-67f06af15bc9
 )
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/text v0.3.3 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)
@@ -0,0 +1,136 @@
+// Package tags parses the `ksql` struct tags which name columns and STRUCT fields, so that the client, the builder and ksqltypes follow the same rules
+package tags
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Name is the struct tag naming columns and STRUCT fields, e.g. `ksql:"ORDER_ID"`
+const Name = "ksql"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+var plainIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reserved lists the keywords which must be quoted to be used as identifiers
+var reserved = map[string]bool{
+	"ALL": true, "AND": true, "AS": true, "BETWEEN": true, "BY": true, "CASE": true, "CAST": true,
+	"CREATE": true, "DELETE": true, "DROP": true, "ELSE": true, "EMIT": true, "END": true, "EXISTS": true,
+	"FALSE": true, "FROM": true, "FULL": true, "GROUP": true, "HAVING": true, "IN": true, "INNER": true,
+	"INSERT": true, "INTO": true, "IS": true, "JOIN": true, "KEY": true, "LEFT": true, "LIKE": true,
+	"LIMIT": true, "NOT": true, "NULL": true, "ON": true, "OR": true, "OUTER": true, "PARTITION": true,
+	"PRIMARY": true, "RIGHT": true, "SELECT": true, "STREAM": true, "TABLE": true, "THEN": true,
+	"TRUE": true, "VALUES": true, "WHEN": true, "WHERE": true, "WINDOW": true, "WITH": true, "WITHIN": true,
+}
+
+// Unquoted reports whether a name can be written without quotes, in which case ksqlDB upper-cases it. Any other name must be quoted, and keeps its case.
+func Unquoted(name string) bool {
+	return plainIdentifier.MatchString(name) && !reserved[strings.ToUpper(name)]
+}
+
+// Field is a struct field which maps to a column or STRUCT field
+type Field struct {
+	// Name is the name in the tag, or the Go field name, as written
+	Name string
+	// Index is the index sequence of the field for reflect.Value.FieldByIndex, through any embedded structs
+	Index []int
+	// Type is the type of the field
+	Type reflect.Type
+	// Key is set by the key option, e.g. `ksql:"id,key"`
+	Key bool
+	// Precision and Scale are set by the precision and scale options, e.g. `ksql:"price,precision=10,scale=2"`
+	Precision int
+	Scale     int
+}
+
+// Fields returns the fields of a struct type, in order.
+//
+// Fields are named by their tag, falling back to the field name, and fields tagged "-" or unexported are skipped. Embedded structs without a name in their tag are flattened as they are by encoding/json, except for time.Time, which is a field like any other. Names are matched case-insensitively, and a name used more than once belongs to the field nearest the root struct, or the first of those at the same depth. Unknown tag options are errors.
+func Fields(t reflect.Type) ([]Field, error) {
+	var all []Field
+	if err := collect(t, nil, &all); err != nil {
+		return nil, err
+	}
+	nearest := make(map[string]int, len(all))
+	for i, f := range all {
+		name := strings.ToUpper(f.Name)
+		if j, ok := nearest[name]; !ok || len(f.Index) < len(all[j].Index) {
+			nearest[name] = i
+		}
+	}
+	fields := make([]Field, 0, len(nearest))
+	for i, f := range all {
+		if nearest[strings.ToUpper(f.Name)] == i {
+			fields = append(fields, f)
+		}
+	}
+	return fields, nil
+}
+
+func collect(t reflect.Type, parent []int, fields *[]Field) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		raw := f.Tag.Get(Name)
+		if raw == "-" {
+			continue
+		}
+		field, err := parse(f, raw)
+		if err != nil {
+			return err
+		}
+		field.Index = append(append([]int{}, parent...), i)
+		if f.Anonymous && field.Name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && ft != timeType {
+				// a nil pointer to an unexported struct can't be allocated when scanning
+				if f.PkgPath == "" || f.Type.Kind() != reflect.Ptr {
+					if err := collect(ft, field.Index, fields); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		if field.Name == "" {
+			field.Name = f.Name
+		}
+		*fields = append(*fields, field)
+	}
+	return nil
+}
+
+// parse parses the tag of a field, leaving the name empty if the tag has none
+func parse(f reflect.StructField, raw string) (Field, error) {
+	parts := strings.Split(raw, ",")
+	field := Field{Name: parts[0], Type: f.Type}
+	for _, opt := range parts[1:] {
+		name, value, _ := strings.Cut(strings.TrimSpace(opt), "=")
+		var err error
+		switch name {
+		case "key":
+			field.Key = true
+		case "precision":
+			field.Precision, err = strconv.Atoi(value)
+		case "scale":
+			field.Scale, err = strconv.Atoi(value)
+		default:
+			err = errors.New("unknown option")
+		}
+		if err != nil {
+			return field, fmt.Errorf("invalid tag option %q on field %s: %w", opt, f.Name, err)
+		}
+	}
+	return field, nil
+}
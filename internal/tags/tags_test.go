@@ -0,0 +1,79 @@
+package tags
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type base struct {
+	ID   int64  `ksql:"id"`
+	Name string `ksql:"name"`
+}
+
+type Audit struct {
+	By string
+}
+
+type unexportedPtr struct {
+	Hidden string
+}
+
+type row struct {
+	base
+	*Audit
+	*unexportedPtr
+	time.Time
+	Name    string `ksql:"NAME"`
+	Price   string `ksql:"price,precision=10,scale=2"`
+	Key     string `ksql:"k,key"`
+	Skipped string `ksql:"-"`
+	private string
+}
+
+func TestFields(t *testing.T) {
+	t.Run("it should follow the rules of the ksql tag", func(t *testing.T) {
+		got, err := Fields(reflect.TypeOf(row{}))
+		assert.NoError(t, err)
+		var names []string
+		for _, f := range got {
+			names = append(names, f.Name)
+		}
+		assert.Equal(t, []string{"id", "By", "Time", "NAME", "price", "k"}, names, "fields nearer the root should win")
+		assert.Equal(t, []int{0, 0}, got[0].Index)
+		assert.Equal(t, []int{1, 0}, got[1].Index)
+		assert.Equal(t, reflect.TypeOf(time.Time{}), got[2].Type)
+		assert.Equal(t, Field{Name: "price", Index: []int{5}, Type: reflect.TypeOf(""), Precision: 10, Scale: 2}, got[4])
+		assert.True(t, got[5].Key)
+	})
+
+	t.Run("it should keep the first of the fields at the same depth with the same name", func(t *testing.T) {
+		got, err := Fields(reflect.TypeOf(struct {
+			A string `ksql:"x"`
+			B string `ksql:"X"`
+		}{}))
+		assert.NoError(t, err)
+		assert.Len(t, got, 1)
+		assert.Equal(t, []int{0}, got[0].Index)
+	})
+
+	t.Run("it should reject unknown and invalid options", func(t *testing.T) {
+		_, err := Fields(reflect.TypeOf(struct {
+			N int `ksql:"n,unknown"`
+		}{}))
+		assert.Error(t, err)
+		_, err = Fields(reflect.TypeOf(struct {
+			base `ksql:",precision=x"`
+		}{}))
+		assert.Error(t, err)
+	})
+}
+
+func TestUnquoted(t *testing.T) {
+	assert.True(t, Unquoted("order_id"))
+	assert.False(t, Unquoted("order-id"))
+	assert.False(t, Unquoted("key"))
+	assert.False(t, Unquoted("1st"))
+}
@@ -0,0 +1,2 @@
+// Package ksqltypes converts between Go values and ksqlDB types
+package ksqltypes
@@ -0,0 +1,253 @@
+package ksqltypes
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vancelongwill/ksql-go/internal/tags"
+)
+
+// ErrUnsupportedType is returned when a Go value has no equivalent ksqlDB literal
+var ErrUnsupportedType = errors.New("unsupported type")
+
+// TimestampFormat is the layout of TIMESTAMP literals. Times are converted to UTC before they are formatted.
+const TimestampFormat = "2006-01-02T15:04:05.000"
+
+// jsonNumberPattern matches the number literals which are also valid JSON numbers
+var jsonNumberPattern = regexp.MustCompile(`^-?(0|[1-9]\d*)(\.\d+)?([eE][+-]?\d+)?$`)
+
+// IsJSONNumber reports whether s is a number in the JSON grammar, which is also a valid ksqlDB number literal once negatives are parenthesised
+func IsJSONNumber(s string) bool {
+	return jsonNumberPattern.MatchString(s)
+}
+
+// QuoteIdentifier quotes a column, source or field name with backticks, so that it keeps its case and may contain any character
+func QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// QuoteString quotes a string literal, escaping any single quotes
+func QuoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// Literal renders a Go value as a ksqlDB literal.
+//
+// Strings are quoted and escaped, []byte is decoded with TO_BYTES, time.Time is rendered as a UTC timestamp string, slices and arrays as ARRAY[...], maps as MAP(k := v, ...) and structs as STRUCT(F := v, ...). STRUCT fields are named by their `ksql` tags or their field names, by the same rules as the client and the builder, and names which needn't be quoted are upper-cased as ksqlDB does.
+// Nil pointers, slices, maps and interfaces are NULL, and driver.Valuer implementations are rendered from the value they return, except for Struct, Array, Map and Decimal which are rendered as their ksqlDB types.
+func Literal(v interface{}) (string, error) {
+	var b strings.Builder
+	if err := writeLiteral(&b, reflect.ValueOf(v)); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func writeLiteral(b *strings.Builder, v reflect.Value) error {
+	if !v.IsValid() {
+		b.WriteString("NULL")
+		return nil
+	}
+	switch x := v.Interface().(type) {
+	case literaler:
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			b.WriteString("NULL")
+			return nil
+		}
+		lit, err := x.ksqlLiteral()
+		if err != nil {
+			return err
+		}
+		b.WriteString(lit)
+		return nil
+	case driver.Valuer:
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			b.WriteString("NULL")
+			return nil
+		}
+		value, err := x.Value()
+		if err != nil {
+			return err
+		}
+		return writeLiteral(b, reflect.ValueOf(value))
+	case time.Time:
+		b.WriteString(QuoteString(x.UTC().Format(TimestampFormat)))
+		return nil
+	case json.Number:
+		if !IsJSONNumber(x.String()) {
+			return fmt.Errorf("%w: %q is not a valid number", ErrUnsupportedType, x)
+		}
+		writeNumber(b, x.String())
+		return nil
+	case []byte:
+		if x == nil {
+			b.WriteString("NULL")
+			return nil
+		}
+		fmt.Fprintf(b, "TO_BYTES(%s, 'base64')", QuoteString(base64.StdEncoding.EncodeToString(x)))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			b.WriteString("NULL")
+			return nil
+		}
+		return writeLiteral(b, v.Elem())
+	case reflect.String:
+		b.WriteString(QuoteString(v.String()))
+	case reflect.Bool:
+		if v.Bool() {
+			b.WriteString("TRUE")
+		} else {
+			b.WriteString("FALSE")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeNumber(b, strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		b.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("%w: %v has no literal", ErrUnsupportedType, f)
+		}
+		writeNumber(b, strconv.FormatFloat(f, 'g', -1, v.Type().Bits()))
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			b.WriteString("NULL")
+			return nil
+		}
+		return writeArray(b, v)
+	case reflect.Map:
+		if v.IsNil() {
+			b.WriteString("NULL")
+			return nil
+		}
+		return writeMap(b, v)
+	case reflect.Struct:
+		return writeStruct(b, v)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+	}
+	return nil
+}
+
+// writeNumber writes a number, in parentheses if it's negative so that it can't form a -- comment with a preceding minus
+func writeNumber(b *strings.Builder, n string) {
+	if strings.HasPrefix(n, "-") {
+		b.WriteString("(")
+		b.WriteString(n)
+		b.WriteString(")")
+		return
+	}
+	b.WriteString(n)
+}
+
+func writeArray(b *strings.Builder, v reflect.Value) error {
+	b.WriteString("ARRAY[")
+	for i := 0; i < v.Len(); i++ {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		if err := writeLiteral(b, v.Index(i)); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+	b.WriteString("]")
+	return nil
+}
+
+// writeMap renders the entries of a map sorted by key, so that the output is deterministic
+func writeMap(b *strings.Builder, v reflect.Value) error {
+	type entry struct{ key, value string }
+	entries := make([]entry, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := Literal(iter.Key().Interface())
+		if err != nil {
+			return fmt.Errorf("map key: %w", err)
+		}
+		value, err := Literal(iter.Value().Interface())
+		if err != nil {
+			return fmt.Errorf("map value %s: %w", key, err)
+		}
+		entries = append(entries, entry{key, value})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	b.WriteString("MAP(")
+	for i, e := range entries {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(e.key)
+		b.WriteString(" := ")
+		b.WriteString(e.value)
+	}
+	b.WriteString(")")
+	return nil
+}
+
+func writeStruct(b *strings.Builder, v reflect.Value) error {
+	b.WriteString("STRUCT(")
+	first := true
+	err := eachField(v, func(name string, fv reflect.Value) error {
+		if !first {
+			b.WriteString(", ")
+		}
+		first = false
+		b.WriteString(QuoteIdentifier(name))
+		b.WriteString(" := ")
+		if err := writeLiteral(b, fv); err != nil {
+			return fmt.Errorf("field %s: %w", name, err)
+		}
+		return nil
+	})
+	b.WriteString(")")
+	return err
+}
+
+// eachField calls fn with the name and value of each field of a struct, skipping the fields of nil embedded struct pointers. Unquoted names are upper-cased, as ksqlDB does, so that they match the names declared by the builder.
+func eachField(v reflect.Value, fn func(name string, fv reflect.Value) error) error {
+	fields, err := tags.Fields(v.Type())
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		fv, ok := fieldByIndex(v, f.Index)
+		if !ok {
+			continue
+		}
+		name := f.Name
+		if tags.Unquoted(name) {
+			name = strings.ToUpper(name)
+		}
+		if err := fn(name, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, except that it reports false rather than panicking when an embedded struct pointer is nil
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
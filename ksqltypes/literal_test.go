@@ -0,0 +1,110 @@
+package ksqltypes
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type address struct {
+	Street string
+	City   string `ksql:"town"`
+}
+
+type person struct {
+	address
+	Name    string `ksql:"NAME"`
+	Age     *int
+	Tags    []string
+	Ignored string `ksql:"-"`
+	private string
+}
+
+type Base struct {
+	ID int64
+}
+
+type event struct {
+	Base
+	At time.Time
+}
+
+func TestLiteral(t *testing.T) {
+	age := 42
+	for name, tc := range map[string]struct {
+		in   interface{}
+		want string
+	}{
+		"it should render NULL for nil":                    {nil, "NULL"},
+		"it should quote and escape strings":               {"it's", "'it''s'"},
+		"it should render booleans":                        {true, "TRUE"},
+		"it should render integers":                        {int8(3), "3"},
+		"it should parenthesise negative integers":         {int8(-3), "(-3)"},
+		"it should parenthesise negative floats":           {-1.5, "(-1.5)"},
+		"it should parenthesise negative json numbers":     {json.Number("-2"), "(-2)"},
+		"it should render unsigned integers":               {uint64(math.MaxUint64), "18446744073709551615"},
+		"it should render floats":                          {1.5, "1.5"},
+		"it should render json numbers":                    {json.Number("12.30"), "12.30"},
+		"it should render times as UTC timestamps":         {time.Date(2021, 2, 3, 4, 5, 6, 7e6, time.FixedZone("X", 3600)), "'2021-02-03T03:05:06.007'"},
+		"it should render bytes with TO_BYTES":             {[]byte("hi"), "TO_BYTES('aGk=', 'base64')"},
+		"it should render slices as arrays":                {[]interface{}{1, "a", nil}, "ARRAY[1, 'a', NULL]"},
+		"it should render nil slices as NULL":              {[]string(nil), "NULL"},
+		"it should render maps sorted by key":              {map[string]bool{"b": false, "a": true}, "MAP('a' := TRUE, 'b' := FALSE)"},
+		"it should dereference pointers":                   {&age, "42"},
+		"it should render nil pointers as NULL":            {(*int)(nil), "NULL"},
+		"it should render driver.Valuers from their value": {sql.NullString{String: "x", Valid: true}, "'x'"},
+		"it should render invalid driver.Valuers as NULL":  {sql.NullInt64{}, "NULL"},
+		"it should render structs using their tags": {
+			person{address: address{Street: "1 Road"}, Name: "Bob", Age: &age, Tags: []string{"a"}, Ignored: "x", private: "y"},
+			"STRUCT(`STREET` := '1 Road', `TOWN` := '', `NAME` := 'Bob', `AGE` := 42, `TAGS` := ARRAY['a'])",
+		},
+		"it should keep the case of names which must be quoted": {
+			struct {
+				OrderID string `ksql:"order-id"`
+				Key     int    `ksql:"key"`
+			}{"a", 1},
+			"STRUCT(`order-id` := 'a', `key` := 1)",
+		},
+		"it should flatten exported embedded structs": {
+			event{Base: Base{ID: 1}, At: time.Unix(0, 0)},
+			"STRUCT(`ID` := 1, `AT` := '1970-01-01T00:00:00.000')",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := Literal(tc.in)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	t.Run("it should fail for values without a literal", func(t *testing.T) {
+		for _, in := range []interface{}{math.NaN(), math.Inf(1), make(chan int), func() {}, []interface{}{complex(1, 2)}} {
+			_, err := Literal(in)
+			assert.True(t, errors.Is(err, ErrUnsupportedType), "%T", in)
+		}
+	})
+
+	t.Run("it should fail for structs with unknown tag options", func(t *testing.T) {
+		_, err := Literal(struct {
+			N int `ksql:"n,unknown"`
+		}{})
+		assert.Error(t, err)
+	})
+
+	t.Run("it should fail for json numbers outside the JSON grammar", func(t *testing.T) {
+		for _, in := range []json.Number{"NaN", "Inf", "-Infinity", "1_0", "0x10", "+1", "01", ".5", ""} {
+			_, err := Literal(in)
+			assert.True(t, errors.Is(err, ErrUnsupportedType), "%q", in)
+		}
+	})
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	assert.Equal(t, "`a``b`", QuoteIdentifier("a`b"))
+	assert.Equal(t, "`lower`", QuoteIdentifier("lower"))
+}
@@ -0,0 +1,268 @@
+package ksqltypes
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidDecimal is returned when a value isn't a valid decimal number
+var ErrInvalidDecimal = errors.New("invalid decimal")
+
+// decimalPattern matches plain decimal numbers, without exponents
+var decimalPattern = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)$`)
+
+// literaler is implemented by the types which have a ksqlDB literal other than the one of the value they return as a driver.Valuer
+type literaler interface {
+	ksqlLiteral() (string, error)
+}
+
+// Struct is a STRUCT value, holding the value of each field by name. A nil Struct is NULL.
+type Struct map[string]interface{}
+
+// Scan implements sql.Scanner
+func (s *Struct) Scan(src interface{}) error {
+	if m, ok := src.(map[string]interface{}); ok {
+		*s = m
+		return nil
+	}
+	var m map[string]interface{}
+	if err := scanJSON(src, &m); err != nil {
+		return fmt.Errorf("unable to scan STRUCT: %w", err)
+	}
+	*s = m
+	return nil
+}
+
+// Value implements driver.Valuer, returning the struct as a JSON object
+func (s Struct) Value() (driver.Value, error) {
+	return jsonValue(s == nil, map[string]interface{}(s))
+}
+
+func (s Struct) ksqlLiteral() (string, error) {
+	if s == nil {
+		return "NULL", nil
+	}
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString("STRUCT(")
+	for i, name := range names {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(QuoteIdentifier(name))
+		b.WriteString(" := ")
+		if err := writeLiteral(&b, reflect.ValueOf(s[name])); err != nil {
+			return "", fmt.Errorf("field %s: %w", name, err)
+		}
+	}
+	b.WriteString(")")
+	return b.String(), nil
+}
+
+// Array is an ARRAY value. A nil Array is NULL.
+type Array[T any] []T
+
+// Scan implements sql.Scanner
+func (a *Array[T]) Scan(src interface{}) error {
+	var s []T
+	if err := scanJSON(src, &s); err != nil {
+		return fmt.Errorf("unable to scan ARRAY: %w", err)
+	}
+	*a = s
+	return nil
+}
+
+// Value implements driver.Valuer, returning the array as a JSON array
+func (a Array[T]) Value() (driver.Value, error) {
+	return jsonValue(a == nil, []T(a))
+}
+
+func (a Array[T]) ksqlLiteral() (string, error) {
+	if a == nil {
+		return "NULL", nil
+	}
+	var b strings.Builder
+	err := writeArray(&b, reflect.ValueOf([]T(a)))
+	return b.String(), err
+}
+
+// Map is a MAP value. A nil Map is NULL.
+type Map[K comparable, V any] map[K]V
+
+// Scan implements sql.Scanner
+func (m *Map[K, V]) Scan(src interface{}) error {
+	var v map[K]V
+	if err := scanJSON(src, &v); err != nil {
+		return fmt.Errorf("unable to scan MAP: %w", err)
+	}
+	*m = v
+	return nil
+}
+
+// Value implements driver.Valuer, returning the map as a JSON object
+func (m Map[K, V]) Value() (driver.Value, error) {
+	return jsonValue(m == nil, map[K]V(m))
+}
+
+func (m Map[K, V]) ksqlLiteral() (string, error) {
+	if m == nil {
+		return "NULL", nil
+	}
+	var b strings.Builder
+	err := writeMap(&b, reflect.ValueOf(map[K]V(m)))
+	return b.String(), err
+}
+
+// Decimal is a DECIMAL value held exactly as a decimal number string, such as "-12.34". The empty Decimal is NULL.
+type Decimal string
+
+// ParseDecimal validates a decimal number without an exponent
+func ParseDecimal(s string) (Decimal, error) {
+	if !decimalPattern.MatchString(s) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	return Decimal(s), nil
+}
+
+// String returns the decimal number
+func (d Decimal) String() string {
+	return string(d)
+}
+
+// Float64 returns the decimal as a float64, which may be inexact
+func (d Decimal) Float64() (float64, error) {
+	return strconv.ParseFloat(string(d), 64)
+}
+
+// Scan implements sql.Scanner
+func (d *Decimal) Scan(src interface{}) error {
+	var s string
+	switch v := src.(type) {
+	case nil:
+		*d = ""
+		return nil
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case json.Number:
+		s = v.String()
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return fmt.Errorf("unable to scan %T into a DECIMAL", src)
+	}
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Value implements driver.Valuer, returning the decimal number as a string
+func (d Decimal) Value() (driver.Value, error) {
+	if d == "" {
+		return nil, nil
+	}
+	if _, err := ParseDecimal(string(d)); err != nil {
+		return nil, err
+	}
+	return string(d), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting a JSON number, as in the rows returned by ksqlDB, or a string
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		return d.Scan(s)
+	}
+	return d.Scan(string(b))
+}
+
+func (d Decimal) ksqlLiteral() (string, error) {
+	if d == "" {
+		return "NULL", nil
+	}
+	if _, err := ParseDecimal(string(d)); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	writeNumber(&b, string(d))
+	return b.String(), nil
+}
+
+// scanJSON decodes the JSON produced by the database/sql driver for a composite column, or the decoded value itself, into dest. NULL leaves dest nil.
+func scanJSON(src interface{}, dest interface{}) error {
+	var by []byte
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		by = v
+	case string:
+		by = []byte(v)
+	case []interface{}, map[string]interface{}:
+		var err error
+		if by, err = json.Marshal(v); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported source type %T", src)
+	}
+	// numbers decoded into interface{} values are kept as json.Number, so that nested BIGINT and DECIMAL values are exact
+	dec := json.NewDecoder(bytes.NewReader(by))
+	dec.UseNumber()
+	if err := dec.Decode(dest); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("unexpected data after JSON value %s", by)
+	}
+	return nil
+}
+
+// jsonValue encodes a composite value as JSON
+func jsonValue(isNil bool, v interface{}) (driver.Value, error) {
+	if isNil {
+		return nil, nil
+	}
+	by, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(by), nil
+}
+
+var (
+	_ sql.Scanner   = &Struct{}
+	_ driver.Valuer = Struct{}
+	_ sql.Scanner   = &Array[string]{}
+	_ driver.Valuer = Array[string]{}
+	_ sql.Scanner   = &Map[string, string]{}
+	_ driver.Valuer = Map[string, string]{}
+	_ sql.Scanner   = new(Decimal)
+	_ driver.Valuer = Decimal("")
+)
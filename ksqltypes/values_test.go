@@ -0,0 +1,146 @@
+package ksqltypes
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStruct(t *testing.T) {
+	t.Run("it should scan JSON and decoded objects", func(t *testing.T) {
+		var s Struct
+		assert.NoError(t, s.Scan([]byte(`{"CITY":"London","ZIP":1}`)))
+		assert.Equal(t, Struct{"CITY": "London", "ZIP": json.Number("1")}, s)
+		assert.NoError(t, s.Scan(map[string]interface{}{"A": true}))
+		assert.Equal(t, Struct{"A": true}, s)
+		assert.NoError(t, s.Scan(nil))
+		assert.Nil(t, s)
+		assert.Error(t, s.Scan(int64(1)))
+		assert.Error(t, s.Scan([]byte(`{"A":1} {}`)))
+	})
+
+	t.Run("it should keep nested numbers exact", func(t *testing.T) {
+		var s Struct
+		assert.NoError(t, s.Scan([]byte(`{"ID":9007199254740993,"ITEMS":[9007199254740993]}`)))
+		assert.Equal(t, Struct{"ID": json.Number("9007199254740993"), "ITEMS": []interface{}{json.Number("9007199254740993")}}, s)
+		var m Map[string, interface{}]
+		assert.NoError(t, m.Scan(map[string]interface{}{"ID": json.Number("9007199254740993")}))
+		assert.Equal(t, Map[string, interface{}]{"ID": json.Number("9007199254740993")}, m)
+	})
+
+	t.Run("it should convert to JSON and a STRUCT literal", func(t *testing.T) {
+		s := Struct{"b": 1, "a": "x"}
+		v, err := s.Value()
+		assert.NoError(t, err)
+		assert.Equal(t, `{"a":"x","b":1}`, v)
+		lit, err := Literal(s)
+		assert.NoError(t, err)
+		assert.Equal(t, "STRUCT(`a` := 'x', `b` := 1)", lit)
+		lit, err = Literal(Struct(nil))
+		assert.NoError(t, err)
+		assert.Equal(t, "NULL", lit)
+		lit, err = Literal((*Struct)(nil))
+		assert.NoError(t, err)
+		assert.Equal(t, "NULL", lit)
+	})
+}
+
+func TestArray(t *testing.T) {
+	t.Run("it should scan elements of the given type", func(t *testing.T) {
+		var a Array[int64]
+		assert.NoError(t, a.Scan([]byte(`[1,2,3]`)))
+		assert.Equal(t, Array[int64]{1, 2, 3}, a)
+		assert.NoError(t, a.Scan([]interface{}{float64(4)}))
+		assert.Equal(t, Array[int64]{4}, a)
+		assert.Error(t, a.Scan([]byte(`["x"]`)))
+		assert.NoError(t, a.Scan(nil))
+		assert.Nil(t, a)
+	})
+
+	t.Run("it should convert to JSON and an ARRAY literal", func(t *testing.T) {
+		a := Array[string]{"a", "b"}
+		v, err := a.Value()
+		assert.NoError(t, err)
+		assert.Equal(t, `["a","b"]`, v)
+		lit, err := Literal(a)
+		assert.NoError(t, err)
+		assert.Equal(t, "ARRAY['a', 'b']", lit)
+		v, err = Array[string](nil).Value()
+		assert.NoError(t, err)
+		assert.Nil(t, v)
+	})
+}
+
+func TestMap(t *testing.T) {
+	t.Run("it should scan keys and values of the given types", func(t *testing.T) {
+		var m Map[string, float64]
+		assert.NoError(t, m.Scan(`{"a":1.5}`))
+		assert.Equal(t, Map[string, float64]{"a": 1.5}, m)
+		assert.NoError(t, m.Scan(map[string]interface{}{"b": float64(2)}))
+		assert.Equal(t, Map[string, float64]{"b": 2}, m)
+	})
+
+	t.Run("it should convert to JSON and a MAP literal", func(t *testing.T) {
+		m := Map[string, int]{"b": 2, "a": 1}
+		v, err := m.Value()
+		assert.NoError(t, err)
+		assert.Equal(t, `{"a":1,"b":2}`, v)
+		lit, err := Literal(m)
+		assert.NoError(t, err)
+		assert.Equal(t, "MAP('a' := 1, 'b' := 2)", lit)
+	})
+}
+
+func TestDecimal(t *testing.T) {
+	t.Run("it should scan numbers and strings exactly", func(t *testing.T) {
+		var d Decimal
+		for src, want := range map[interface{}]Decimal{
+			"12345678901234567890.12": "12345678901234567890.12",
+			float64(1.25):             "1.25",
+			int64(-3):                 "-3",
+			nil:                       "",
+		} {
+			assert.NoError(t, d.Scan(src))
+			assert.Equal(t, want, d)
+		}
+		assert.NoError(t, d.Scan([]byte(".5")))
+		assert.Equal(t, Decimal(".5"), d)
+		assert.True(t, errors.Is(d.Scan("1e5"), ErrInvalidDecimal))
+		assert.Error(t, d.Scan(true))
+	})
+
+	t.Run("it should convert to a string and an unquoted literal", func(t *testing.T) {
+		d, err := ParseDecimal("-0.10")
+		assert.NoError(t, err)
+		v, err := d.Value()
+		assert.NoError(t, err)
+		assert.Equal(t, "-0.10", v)
+		lit, err := Literal(d)
+		assert.NoError(t, err)
+		assert.Equal(t, "(-0.10)", lit)
+		f, err := d.Float64()
+		assert.NoError(t, err)
+		assert.Equal(t, -0.1, f)
+
+		v, err = Decimal("").Value()
+		assert.NoError(t, err)
+		assert.Nil(t, v)
+		_, err = Literal(Decimal("1; DROP STREAM s1"))
+		assert.True(t, errors.Is(err, ErrInvalidDecimal))
+	})
+
+	t.Run("it should decode JSON numbers and strings exactly", func(t *testing.T) {
+		var row struct {
+			Price *Decimal
+			Total Decimal
+			Tax   Decimal
+		}
+		assert.NoError(t, json.Unmarshal([]byte(`{"PRICE": null, "TOTAL": 12345678901234567890.12, "TAX": "-0.10"}`), &row))
+		assert.Nil(t, row.Price)
+		assert.Equal(t, Decimal("12345678901234567890.12"), row.Total)
+		assert.Equal(t, Decimal("-0.10"), row.Tax)
+		assert.True(t, errors.Is(json.Unmarshal([]byte(`{"TOTAL": 1e5}`), &row), ErrInvalidDecimal))
+	})
+}
@@ -0,0 +1,166 @@
+package lexer
+
+import "fmt"
+
+// StatementKind is the kind of a statement
+type StatementKind int
+
+const (
+	// Unknown is a statement which isn't recognised
+	Unknown StatementKind = iota
+	// PullQuery is a SELECT which returns the current state and completes
+	PullQuery
+	// PushQuery is a SELECT ... EMIT CHANGES which streams results until it's closed
+	PushQuery
+	// DDL is a CREATE, DROP or ALTER statement, including CREATE ... AS SELECT
+	DDL
+	// InsertValues is an INSERT INTO ... VALUES statement
+	InsertValues
+	// InsertQuery is an INSERT INTO ... SELECT statement, which starts a persistent query
+	InsertQuery
+	// Set is a SET statement which sets a property
+	Set
+	// Unset is an UNSET statement which removes a property
+	Unset
+	// Define is a DEFINE statement which defines a variable
+	Define
+	// Undefine is an UNDEFINE statement which removes a variable
+	Undefine
+	// Describe is a DESCRIBE statement
+	Describe
+	// Explain is an EXPLAIN statement
+	Explain
+	// List is a LIST or SHOW statement
+	List
+	// Print is a PRINT statement which streams the contents of a topic
+	Print
+	// Terminate is a TERMINATE statement which stops a persistent query
+	Terminate
+	// Pause is a PAUSE statement which pauses a persistent query
+	Pause
+	// Resume is a RESUME statement which resumes a paused persistent query
+	Resume
+)
+
+var statementKindNames = [...]string{
+	Unknown:      "Unknown",
+	PullQuery:    "PullQuery",
+	PushQuery:    "PushQuery",
+	DDL:          "DDL",
+	InsertValues: "InsertValues",
+	InsertQuery:  "InsertQuery",
+	Set:          "Set",
+	Unset:        "Unset",
+	Define:       "Define",
+	Undefine:     "Undefine",
+	Describe:     "Describe",
+	Explain:      "Explain",
+	List:         "List",
+	Print:        "Print",
+	Terminate:    "Terminate",
+	Pause:        "Pause",
+	Resume:       "Resume",
+}
+
+func (k StatementKind) String() string {
+	if k < 0 || int(k) >= len(statementKindNames) {
+		return fmt.Sprintf("StatementKind(%d)", int(k))
+	}
+	return statementKindNames[k]
+}
+
+// IsQuery reports whether the statement is a pull or push query, which must be run with the query endpoints rather than Exec
+func (k StatementKind) IsQuery() bool {
+	return k == PullQuery || k == PushQuery
+}
+
+// leadingKeywords maps the keyword starting a statement to its kind, for the statements which can be classified by their first keyword alone
+var leadingKeywords = map[string]StatementKind{
+	"CREATE":    DDL,
+	"DROP":      DDL,
+	"ALTER":     DDL,
+	"SET":       Set,
+	"UNSET":     Unset,
+	"DEFINE":    Define,
+	"UNDEFINE":  Undefine,
+	"DESCRIBE":  Describe,
+	"EXPLAIN":   Explain,
+	"LIST":      List,
+	"SHOW":      List,
+	"PRINT":     Print,
+	"TERMINATE": Terminate,
+	"PAUSE":     Pause,
+	"RESUME":    Resume,
+}
+
+// Classify returns the kind of a single statement
+func Classify(statement string) (StatementKind, error) {
+	tokens, err := Tokenize(statement)
+	if err != nil {
+		return Unknown, err
+	}
+	return ClassifyTokens(tokens), nil
+}
+
+// ClassifyTokens returns the kind of the statement made up of tokens
+func ClassifyTokens(tokens []Token) StatementKind {
+	tokens = Significant(tokens)
+	if len(tokens) == 0 || tokens[0].Kind != Identifier {
+		return Unknown
+	}
+	switch {
+	case tokens[0].IsKeyword("SELECT"):
+		if hasTopLevelKeyword(tokens, "EMIT") {
+			return PushQuery
+		}
+		return PullQuery
+	case tokens[0].IsKeyword("INSERT"):
+		if hasTopLevelKeyword(tokens, "VALUES") {
+			return InsertValues
+		}
+		return InsertQuery
+	}
+	for kw, kind := range leadingKeywords {
+		if tokens[0].IsKeyword(kw) {
+			return kind
+		}
+	}
+	return Unknown
+}
+
+// Significant returns the tokens which aren't whitespace or comments
+func Significant(tokens []Token) []Token {
+	significant := make([]Token, 0, len(tokens))
+	for _, tok := range tokens {
+		if !tok.IsTrivia() {
+			significant = append(significant, tok)
+		}
+	}
+	return significant
+}
+
+// IsTerminated reports whether the last significant token is a semicolon
+func IsTerminated(tokens []Token) bool {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		if !tokens[i].IsTrivia() {
+			return tokens[i].Kind == Semicolon
+		}
+	}
+	return false
+}
+
+// hasTopLevelKeyword reports whether the keyword appears outside of any parentheses
+func hasTopLevelKeyword(tokens []Token, kw string) bool {
+	depth := 0
+	for _, tok := range tokens {
+		switch {
+		case tok.Kind == Operator && tok.Text == "(":
+			depth++
+		case tok.Kind == Operator && tok.Text == ")":
+			depth--
+		case depth == 0 && tok.IsKeyword(kw):
+			return true
+		}
+	}
+	return false
+}
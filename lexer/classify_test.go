@@ -0,0 +1,56 @@
+package lexer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	for statement, want := range map[string]StatementKind{
+		"SELECT * FROM t1 WHERE k = 'k1';":                   PullQuery,
+		"select * from s1 emit changes;":                     PushQuery,
+		"-- leading comment\nSELECT * FROM s1 EMIT CHANGES;": PushQuery,
+		"SELECT 'EMIT CHANGES' AS `EMIT` FROM t1;":           PullQuery,
+		"CREATE STREAM s2 AS SELECT * FROM s1 EMIT CHANGES;": DDL,
+		"DROP TABLE t1 DELETE TOPIC;":                        DDL,
+		"INSERT INTO s1 (k, v) VALUES ('a', 1);":             InsertValues,
+		"INSERT INTO s1 SELECT * FROM s2 EMIT CHANGES;":      InsertQuery,
+		"SET 'auto.offset.reset' = 'earliest';":              Set,
+		"UNSET 'auto.offset.reset';":                         Unset,
+		"DEFINE topic = 'orders';":                           Define,
+		"UNDEFINE topic;":                                    Undefine,
+		"DESCRIBE s1 EXTENDED;":                              Describe,
+		"EXPLAIN q1;":                                        Explain,
+		"SHOW STREAMS;":                                      List,
+		"LIST TABLES;":                                       List,
+		"PRINT 'topic' FROM BEGINNING;":                      Print,
+		"TERMINATE ALL;":                                     Terminate,
+		"PAUSE q1;":                                          Pause,
+		"RESUME q1;":                                         Resume,
+		"GRANT everything;":                                  Unknown,
+		"":                                                   Unknown,
+	} {
+		got, err := Classify(statement)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got, statement)
+	}
+	t.Run("it should report which kinds are queries", func(t *testing.T) {
+		assert.True(t, PullQuery.IsQuery())
+		assert.True(t, PushQuery.IsQuery())
+		assert.False(t, InsertQuery.IsQuery())
+	})
+}
+
+func TestIsTerminated(t *testing.T) {
+	for statement, want := range map[string]bool{
+		"LIST STREAMS;":                true,
+		"LIST STREAMS; -- a comment\n": true,
+		"LIST STREAMS":                 false,
+		"SELECT ';' FROM t1":           false,
+	} {
+		tokens, err := Tokenize(statement)
+		assert.NoError(t, err)
+		assert.Equal(t, want, IsTerminated(tokens), statement)
+	}
+}
@@ -0,0 +1,2 @@
+// Package lexer tokenizes statements in ksqlDB's dialect of SQL and classifies them by kind
+package lexer
@@ -0,0 +1,184 @@
+package lexer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrUnterminated is returned when the input ends inside a string literal, quoted identifier, block comment or variable
+var ErrUnterminated = errors.New("unterminated")
+
+// operators lists the operators made up of more than one character
+var operators = []string{"->", ":=", "<=", ">=", "<>", "!=", "||"}
+
+// Lexer splits a statement into tokens
+type Lexer struct {
+	src       string
+	pos       int
+	line, col int
+}
+
+// New returns a lexer for src
+func New(src string) *Lexer {
+	return &Lexer{src: src, line: 1, col: 1}
+}
+
+// Tokenize returns all of the tokens in src, excluding the final EOF token
+func Tokenize(src string) ([]Token, error) {
+	l := New(src)
+	var tokens []Token
+	for {
+		tok, err := l.Next()
+		if err != nil {
+			return nil, err
+		}
+		if tok.Kind == EOF {
+			return tokens, nil
+		}
+		tokens = append(tokens, tok)
+	}
+}
+
+// Next returns the next token, or a token of kind EOF once the input has been consumed
+func (l *Lexer) Next() (Token, error) {
+	tok := Token{Offset: l.pos, Line: l.line, Column: l.col}
+	if l.pos >= len(l.src) {
+		return tok, nil
+	}
+	kind, n, err := l.scan(l.src[l.pos:])
+	if err != nil {
+		return tok, fmt.Errorf("%w %s at line %d, column %d", ErrUnterminated, err, l.line, l.col)
+	}
+	tok.Kind = kind
+	tok.Text = l.src[l.pos : l.pos+n]
+	l.advance(tok.Text)
+	return tok, nil
+}
+
+// scan returns the kind and length of the token at the start of s
+func (l *Lexer) scan(s string) (Kind, int, error) {
+	r, size := utf8.DecodeRuneInString(s)
+	switch {
+	case unicode.IsSpace(r):
+		return Whitespace, len(s) - len(strings.TrimLeftFunc(s, unicode.IsSpace)), nil
+	case strings.HasPrefix(s, "--"):
+		if i := strings.IndexByte(s, '\n'); i >= 0 {
+			return Comment, i, nil
+		}
+		return Comment, len(s), nil
+	case strings.HasPrefix(s, "/*"):
+		i := strings.Index(s[2:], "*/")
+		if i < 0 {
+			return 0, 0, errors.New("block comment")
+		}
+		return Comment, i + 4, nil
+	case r == '\'':
+		n, err := scanQuoted(s, "string literal")
+		return String, n, err
+	case r == '`' || r == '"':
+		n, err := scanQuoted(s, "quoted identifier")
+		return QuotedIdentifier, n, err
+	case strings.HasPrefix(s, "${"):
+		i := strings.IndexByte(s, '}')
+		if i < 0 {
+			return 0, 0, errors.New("variable")
+		}
+		return Variable, i + 1, nil
+	case r == '$' && len(s) > 1 && isDigit(s[1]):
+		return Placeholder, 1 + countFunc(s[1:], func(r rune) bool { return r < utf8.RuneSelf && isDigit(byte(r)) }), nil
+	case r == '?':
+		return Placeholder, 1, nil
+	case r == ':' && len(s) > 1 && isWordStart(rune(s[1])):
+		return Placeholder, 1 + countFunc(s[1:], isWordPart), nil
+	case isWordStart(r):
+		return Identifier, countFunc(s, isWordPart), nil
+	case r < utf8.RuneSelf && isDigit(byte(r)), r == '.' && len(s) > 1 && isDigit(s[1]):
+		return Number, scanNumber(s), nil
+	case r == ';':
+		return Semicolon, 1, nil
+	}
+	for _, op := range operators {
+		if strings.HasPrefix(s, op) {
+			return Operator, len(op), nil
+		}
+	}
+	return Operator, size, nil
+}
+
+// scanQuoted returns the length of the quoted text at the start of s, where quotes are escaped by doubling them
+func scanQuoted(s string, what string) (int, error) {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		if s[i] != q {
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == q {
+			i++
+			continue
+		}
+		return i + 1, nil
+	}
+	return 0, errors.New(what)
+}
+
+// scanNumber returns the length of the number at the start of s
+func scanNumber(s string) int {
+	i := 0
+	digits := func() {
+		for i < len(s) && isDigit(s[i]) {
+			i++
+		}
+	}
+	digits()
+	if i < len(s) && s[i] == '.' {
+		i++
+		digits()
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		j := i + 1
+		if j < len(s) && (s[j] == '+' || s[j] == '-') {
+			j++
+		}
+		if j < len(s) && isDigit(s[j]) {
+			i = j
+			digits()
+		}
+	}
+	return i
+}
+
+// advance moves the position of the lexer past s
+func (l *Lexer) advance(s string) {
+	l.pos += len(s)
+	for _, r := range s {
+		if r == '\n' {
+			l.line++
+			l.col = 1
+		} else {
+			l.col++
+		}
+	}
+}
+
+// countFunc returns the length in bytes of the prefix of s whose runes satisfy f
+func countFunc(s string, f func(rune) bool) int {
+	if i := strings.IndexFunc(s, func(r rune) bool { return !f(r) }); i >= 0 {
+		return i
+	}
+	return len(s)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isWordStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isWordPart(r rune) bool {
+	return r == '_' || r == '@' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,81 @@
+package lexer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tok struct {
+	Kind Kind
+	Text string
+}
+
+func kinds(tokens []Token) []tok {
+	var got []tok
+	for _, t := range Significant(tokens) {
+		got = append(got, tok{t.Kind, t.Text})
+	}
+	return got
+}
+
+func TestTokenize(t *testing.T) {
+	t.Run("it should tokenize identifiers, literals and operators", func(t *testing.T) {
+		tokens, err := Tokenize("SELECT `k`, \"V 1\", s->f, 'it''s', 1.5e3, .5 FROM t1 WHERE m['a'] <> 42;")
+		assert.NoError(t, err)
+		assert.Equal(t, []tok{
+			{Identifier, "SELECT"}, {QuotedIdentifier, "`k`"}, {Operator, ","},
+			{QuotedIdentifier, `"V 1"`}, {Operator, ","},
+			{Identifier, "s"}, {Operator, "->"}, {Identifier, "f"}, {Operator, ","},
+			{String, "'it''s'"}, {Operator, ","},
+			{Number, "1.5e3"}, {Operator, ","}, {Number, ".5"},
+			{Identifier, "FROM"}, {Identifier, "t1"}, {Identifier, "WHERE"},
+			{Identifier, "m"}, {Operator, "["}, {String, "'a'"}, {Operator, "]"}, {Operator, "<>"}, {Number, "42"},
+			{Semicolon, ";"},
+		}, kinds(tokens))
+	})
+	t.Run("it should tokenize variables and placeholders", func(t *testing.T) {
+		tokens, err := Tokenize("WITH (kafka_topic='${topic}', partitions=${n}) WHERE a = $1 AND b = :name AND c = ? AND MAP('k' := $10)")
+		assert.NoError(t, err)
+		var got []tok
+		for _, t := range kinds(tokens) {
+			if t.Kind == Variable || t.Kind == Placeholder || t.Text == ":=" {
+				got = append(got, t)
+			}
+		}
+		assert.Equal(t, []tok{
+			{Variable, "${n}"}, {Placeholder, "$1"}, {Placeholder, ":name"}, {Placeholder, "?"},
+			{Operator, ":="}, {Placeholder, "$10"},
+		}, got, "variables inside string literals should be left alone")
+	})
+	t.Run("it should keep comments and whitespace with their positions", func(t *testing.T) {
+		tokens, err := Tokenize("-- hi\n /* a\nb */x")
+		assert.NoError(t, err)
+		assert.Equal(t, []Token{
+			{Kind: Comment, Text: "-- hi", Offset: 0, Line: 1, Column: 1},
+			{Kind: Whitespace, Text: "\n ", Offset: 5, Line: 1, Column: 6},
+			{Kind: Comment, Text: "/* a\nb */", Offset: 7, Line: 2, Column: 2},
+			{Kind: Identifier, Text: "x", Offset: 16, Line: 3, Column: 5},
+		}, tokens)
+	})
+	t.Run("it should fail when the input ends inside a token", func(t *testing.T) {
+		for _, src := range []string{"'abc", "`abc", `"abc`, "/* abc", "${abc"} {
+			_, err := Tokenize(src)
+			assert.True(t, errors.Is(err, ErrUnterminated), src)
+		}
+	})
+}
+
+func TestTokenValue(t *testing.T) {
+	for text, want := range map[string]Token{
+		"it's": {Kind: String, Text: "'it''s'"},
+		"a`b":  {Kind: QuotedIdentifier, Text: "`a``b`"},
+		"x":    {Kind: Variable, Text: "${x}"},
+		"name": {Kind: Placeholder, Text: ":name"},
+		"2":    {Kind: Placeholder, Text: "$2"},
+		"abc":  {Kind: Identifier, Text: "abc"},
+	} {
+		assert.Equal(t, text, want.Value())
+	}
+}
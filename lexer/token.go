@@ -0,0 +1,94 @@
+package lexer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind is the kind of a token
+type Kind int
+
+const (
+	// EOF marks the end of the input
+	EOF Kind = iota
+	// Whitespace is a run of spaces, tabs and newlines
+	Whitespace
+	// Comment is a line comment starting with -- or a block comment between /* and */
+	Comment
+	// Identifier is an unquoted word, which includes keywords such as SELECT
+	Identifier
+	// QuotedIdentifier is an identifier quoted with backticks or double quotes
+	QuotedIdentifier
+	// String is a single quoted string literal
+	String
+	// Number is an integer or decimal literal, optionally with an exponent
+	Number
+	// Variable is a variable reference, e.g. ${topic}
+	Variable
+	// Placeholder is a parameter placeholder, one of ?, $1 or :name
+	Placeholder
+	// Operator is an operator or punctuation, e.g. ( or ->
+	Operator
+	// Semicolon terminates a statement
+	Semicolon
+)
+
+var kindNames = [...]string{
+	EOF:              "EOF",
+	Whitespace:       "Whitespace",
+	Comment:          "Comment",
+	Identifier:       "Identifier",
+	QuotedIdentifier: "QuotedIdentifier",
+	String:           "String",
+	Number:           "Number",
+	Variable:         "Variable",
+	Placeholder:      "Placeholder",
+	Operator:         "Operator",
+	Semicolon:        "Semicolon",
+}
+
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+	return kindNames[k]
+}
+
+// Token is a single token of a statement
+type Token struct {
+	Kind Kind
+	// Text is the source text of the token, including any quotes or delimiters
+	Text string
+	// Offset is the byte offset of the token in the input
+	Offset int
+	// Line and Column are the 1-based position of the token in the input
+	Line   int
+	Column int
+}
+
+// IsTrivia reports whether the token is whitespace or a comment, which have no meaning to ksqlDB
+func (t Token) IsTrivia() bool {
+	return t.Kind == Whitespace || t.Kind == Comment
+}
+
+// IsKeyword reports whether the token is the unquoted keyword kw, ignoring case
+func (t Token) IsKeyword(kw string) bool {
+	return t.Kind == Identifier && strings.EqualFold(t.Text, kw)
+}
+
+// Value returns the unquoted value of a string literal, quoted identifier or variable, the name of a named placeholder or the position of a positional placeholder, and the text of any other token
+func (t Token) Value() string {
+	switch t.Kind {
+	case String, QuotedIdentifier:
+		q := t.Text[:1]
+		return strings.ReplaceAll(t.Text[1:len(t.Text)-1], q+q, q)
+	case Variable:
+		return t.Text[2 : len(t.Text)-1]
+	case Placeholder:
+		if t.Text == "?" {
+			return ""
+		}
+		return t.Text[1:]
+	}
+	return t.Text
+}
@@ -0,0 +1,2 @@
+// Package loader bulk loads CSV and newline delimited JSON files into a ksqlDB stream via the inserts stream endpoint
+package loader
@@ -0,0 +1,271 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	ksql "github.com/vancelongwill/ksql-go/client"
+)
+
+const (
+	// DefaultProgressInterval is how often progress is reported by default
+	DefaultProgressInterval = time.Second
+	// maxRecordedFailures limits the number of failures kept in the summary, all failures are still counted
+	maxRecordedFailures = 100
+)
+
+// Config configures a load
+type Config struct {
+	// Target is the name of the stream to insert the rows into
+	Target string
+	// Format is the format of the file being loaded
+	Format Format
+	// Columns renames fields in the file (CSV headers or JSON object keys) to column names. Fields mapped to "-" are skipped, and fields which aren't mapped keep their names.
+	Columns map[string]string
+	// Parallelism is the number of inserts streams rows are written to concurrently, defaulting to 1. Rows are only inserted in the order of the file when it is 1.
+	Parallelism int
+	// MaxInFlight limits the number of rows awaiting an ack on each inserts stream, see client.WithMaxInFlight
+	MaxInFlight int
+	// Progress is called periodically while loading, and once more when the load finishes
+	Progress func(Progress)
+	// ProgressInterval is how often Progress is called, defaulting to DefaultProgressInterval
+	ProgressInterval time.Duration
+}
+
+// Progress is a snapshot of the number of rows loaded so far
+type Progress struct {
+	// Read is the number of rows read from the file
+	Read int64
+	// Acked is the number of rows acknowledged by the server
+	Acked int64
+	// Failed is the number of rows which couldn't be parsed, didn't match the schema or were rejected by the server
+	Failed int64
+}
+
+// Failure describes a row which couldn't be loaded
+type Failure struct {
+	// Line is the line number of the row in the file
+	Line int
+	// Err is the reason the row couldn't be loaded
+	Err error
+}
+
+// Summary is the outcome of a load
+type Summary struct {
+	Progress
+	// Failures lists the first failed rows, in the order in which they failed
+	Failures []Failure
+	// Duration is the time taken to load the file
+	Duration time.Duration
+}
+
+// counters tracks the progress of a load across workers
+type counters struct {
+	read, acked, failed int64
+
+	mu       sync.Mutex
+	failures []Failure
+}
+
+func (c *counters) progress() Progress {
+	return Progress{
+		Read:   atomic.LoadInt64(&c.read),
+		Acked:  atomic.LoadInt64(&c.acked),
+		Failed: atomic.LoadInt64(&c.failed),
+	}
+}
+
+func (c *counters) fail(line int, err error) {
+	atomic.AddInt64(&c.failed, 1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.failures) < maxRecordedFailures {
+		c.failures = append(c.failures, Failure{Line: line, Err: err})
+	}
+}
+
+// Load streams the rows of r into the target stream.
+//
+// The schema of the target is fetched with DESCRIBE, and CSV values are coerced to the type of their column. Every row is validated against the schema before it is sent, see client.WithSchema.
+// Rows which can't be parsed, don't match the schema or are rejected by the server are counted as failures and don't stop the load. Load only returns an error if the file can't be read or an inserts stream fails, in which case the summary covers the rows loaded so far.
+func Load(ctx context.Context, c ksql.Client, r io.Reader, conf Config) (Summary, error) {
+	start := time.Now()
+	if conf.Parallelism < 1 {
+		conf.Parallelism = 1
+	}
+	if conf.ProgressInterval <= 0 {
+		conf.ProgressInterval = DefaultProgressInterval
+	}
+	describe, err := c.Describe(ctx, conf.Target)
+	if err != nil {
+		return Summary{}, fmt.Errorf("unable to describe %s: %w", conf.Target, err)
+	}
+	src, err := newSource(r, conf, newColumnTypes(describe.SourceDescription))
+	if err != nil {
+		return Summary{}, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// the streams validate rows against the schema which has already been described
+	options := []ksql.InsertsStreamOption{ksql.WithSchema(describe.SourceDescription)}
+	if conf.MaxInFlight > 0 {
+		options = append(options, ksql.WithMaxInFlight(conf.MaxInFlight))
+	}
+	writers := make([]*ksql.InsertsStreamWriter, 0, conf.Parallelism)
+	for i := 0; i < conf.Parallelism; i++ {
+		wtr, err := c.InsertsStream(ctx, ksql.InsertsStreamTargetPayload{Target: conf.Target}, options...)
+		if err != nil {
+			for _, w := range writers {
+				w.Close()
+			}
+			return Summary{}, err
+		}
+		writers = append(writers, wtr)
+	}
+
+	counts := &counters{}
+	records := make(chan record)
+	errs := make(chan error, conf.Parallelism+1)
+	var wg sync.WaitGroup
+	for _, wtr := range writers {
+		wg.Add(1)
+		go func(wtr *ksql.InsertsStreamWriter) {
+			defer wg.Done()
+			if err := insert(ctx, wtr, records, counts); err != nil {
+				errs <- err
+				cancel()
+			}
+		}(wtr)
+	}
+
+	stopProgress := reportProgress(conf, counts)
+	if err := read(ctx, src, records, counts); err != nil {
+		errs <- err
+	}
+	close(records)
+	wg.Wait()
+	stopProgress()
+
+	summary := Summary{
+		Progress: counts.progress(),
+		Failures: counts.failures,
+		Duration: time.Since(start),
+	}
+	if conf.Progress != nil {
+		conf.Progress(summary.Progress)
+	}
+	select {
+	case err := <-errs:
+		return summary, err
+	default:
+		return summary, nil
+	}
+}
+
+func newSource(r io.Reader, conf Config, types columnTypes) (source, error) {
+	switch conf.Format {
+	case CSV:
+		return newCSVSource(r, conf.Columns, types)
+	case NDJSON:
+		return newNDJSONSource(r, conf.Columns), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, conf.Format)
+}
+
+// read sends the records of src to the workers until the file ends or the load is cancelled
+func read(ctx context.Context, src source, records chan<- record, counts *counters) error {
+	for {
+		rec, err := src.next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read the file: %w", err)
+		}
+		atomic.AddInt64(&counts.read, 1)
+		if rec.err != nil {
+			counts.fail(rec.line, rec.err)
+			continue
+		}
+		select {
+		case records <- rec:
+		case <-ctx.Done():
+			// a worker failed, or the load was cancelled
+			return nil
+		}
+	}
+}
+
+// pendingRow is a row which has been written but not yet acknowledged
+type pendingRow struct {
+	line int
+	ack  *ksql.InsertAck
+}
+
+// insert writes records to an inserts stream, counting the rows as their acks are received
+func insert(ctx context.Context, wtr *ksql.InsertsStreamWriter, records <-chan record, counts *counters) error {
+	pending := make(chan pendingRow, ksql.DefaultMaxInFlight)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for p := range pending {
+			<-p.ack.Done()
+			if err := p.ack.Err(); err != nil {
+				counts.fail(p.line, err)
+			} else {
+				atomic.AddInt64(&counts.acked, 1)
+			}
+		}
+	}()
+	var streamErr error
+	for rec := range records {
+		ack, err := wtr.WriteAsync(ctx, rec.row)
+		if errors.Is(err, ksql.ErrInvalidRow) {
+			counts.fail(rec.line, err)
+			continue
+		}
+		if err != nil {
+			streamErr = err
+			break
+		}
+		pending <- pendingRow{line: rec.line, ack: ack}
+	}
+	close(pending)
+	<-done
+	if err := wtr.Close(); err != nil && streamErr == nil {
+		streamErr = err
+	}
+	return streamErr
+}
+
+// reportProgress calls the progress callback periodically until the returned function is called
+func reportProgress(conf Config, counts *counters) func() {
+	if conf.Progress == nil {
+		return func() {}
+	}
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		t := time.NewTicker(conf.ProgressInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				conf.Progress(counts.progress())
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-stopped
+	}
+}
@@ -0,0 +1,245 @@
+package loader
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	ksql "github.com/vancelongwill/ksql-go/client"
+	"golang.org/x/net/http2"
+)
+
+var description = ksql.SourceDescription{
+	Name: "ORDERS",
+	Fields: []ksql.Field{
+		{Name: "ID", Schema: ksql.Schema{Type: "STRING"}, Type: "KEY"},
+		{Name: "QUANTITY", Schema: ksql.Schema{Type: "INTEGER"}},
+		{Name: "PRICE", Schema: ksql.Schema{Type: "DECIMAL"}},
+		{Name: "PAID", Schema: ksql.Schema{Type: "BOOLEAN"}},
+		{Name: "TAGS", Schema: ksql.Schema{Type: "ARRAY", MemberSchema: map[string]interface{}{"type": "STRING"}}},
+	},
+}
+
+// fakeServer describes the ORDERS stream and acks every inserted row, rejecting those with the ID "rejected"
+type fakeServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	inserted  []map[string]interface{}
+	describes int
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	s := &fakeServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ksql", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.describes++
+		s.mu.Unlock()
+		assert.NoError(t, json.NewEncoder(w).Encode([]ksql.ExecResult{{
+			DescribeResult: &ksql.DescribeResult{SourceDescription: description},
+		}}))
+	})
+	mux.HandleFunc("/inserts-stream", func(w http.ResponseWriter, r *http.Request) {
+		dec := json.NewDecoder(r.Body)
+		var target ksql.InsertsStreamTargetPayload
+		assert.NoError(t, dec.Decode(&target))
+		assert.Equal(t, "orders", target.Target)
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		enc := json.NewEncoder(w)
+		for seq := int64(0); ; seq++ {
+			var row map[string]interface{}
+			if err := dec.Decode(&row); err != nil {
+				return
+			}
+			ack := ksql.InsertsStreamAck{Status: "ok", Seq: seq}
+			if row["ID"] == "rejected" {
+				ack = ksql.InsertsStreamAck{Status: "error", Seq: seq, ErrorCode: 40000, Message: "rejected"}
+			} else {
+				s.mu.Lock()
+				s.inserted = append(s.inserted, row)
+				s.mu.Unlock()
+			}
+			assert.NoError(t, enc.Encode(ack))
+			w.(http.Flusher).Flush()
+		}
+	})
+	s.Server = httptest.NewUnstartedServer(mux)
+	s.EnableHTTP2 = true
+	s.StartTLS()
+	return s
+}
+
+func (s *fakeServer) client() ksql.Client {
+	tr := &http.Transport{}
+	if err := http2.ConfigureTransport(tr); err != nil {
+		panic(err)
+	}
+	tr.TLSClientConfig.InsecureSkipVerify = true
+	return ksql.New(s.URL, ksql.WithHTTPClient(&http.Client{Transport: tr}))
+}
+
+func (s *fakeServer) ids() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for _, row := range s.inserted {
+		ids = append(ids, fmt.Sprint(row["ID"]))
+	}
+	return ids
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("it should load a CSV file, coercing values to the column types", func(t *testing.T) {
+		srv := newFakeServer(t)
+		defer srv.Close()
+		file := strings.Join([]string{
+			"order_id,qty,price,paid,tags,ignored",
+			`a,1,1.10,true,"[""x""]",foo`,
+			"b,2,,false,,bar",
+		}, "\n")
+		summary, err := Load(context.Background(), srv.client(), strings.NewReader(file), Config{
+			Target:  "orders",
+			Format:  CSV,
+			Columns: map[string]string{"order_id": "ID", "qty": "QUANTITY", "ignored": "-"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, Progress{Read: 2, Acked: 2}, summary.Progress)
+		assert.Empty(t, summary.Failures)
+		assert.Equal(t, []map[string]interface{}{
+			{"ID": "a", "QUANTITY": float64(1), "PRICE": 1.10, "PAID": true, "TAGS": []interface{}{"x"}},
+			{"ID": "b", "QUANTITY": float64(2), "PRICE": nil, "PAID": false, "TAGS": nil},
+		}, srv.inserted)
+	})
+
+	t.Run("it should load an NDJSON file in parallel", func(t *testing.T) {
+		srv := newFakeServer(t)
+		defer srv.Close()
+		var lines, want []string
+		for i := 0; i < 100; i++ {
+			id := fmt.Sprintf("%03d", i)
+			lines = append(lines, fmt.Sprintf(`{"id": %q, "quantity": %d}`, id, i))
+			want = append(want, id)
+		}
+		var reports int
+		summary, err := Load(context.Background(), srv.client(), strings.NewReader(strings.Join(lines, "\n")), Config{
+			Target:           "orders",
+			Format:           NDJSON,
+			Parallelism:      4,
+			ProgressInterval: time.Millisecond,
+			Progress: func(Progress) {
+				reports++
+			},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, Progress{Read: 100, Acked: 100}, summary.Progress)
+		assert.NotZero(t, reports)
+		assert.Equal(t, 1, srv.describes, "the streams should reuse the described schema")
+		got := srv.ids()
+		sort.Strings(got)
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("it should count rows which fail without stopping the load", func(t *testing.T) {
+		srv := newFakeServer(t)
+		defer srv.Close()
+		file := strings.Join([]string{
+			`{"id": "a", "quantity": 1}`,
+			`not json`,
+			`{"quantity": 2}`,
+			`{"id": "rejected"}`,
+			``,
+			`{"id": "b", "quantity": "two"}`,
+			`{"id": "c"}`,
+		}, "\n")
+		summary, err := Load(context.Background(), srv.client(), strings.NewReader(file), Config{
+			Target: "orders",
+			Format: NDJSON,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, Progress{Read: 6, Acked: 2, Failed: 4}, summary.Progress)
+		var lines []int
+		for _, f := range summary.Failures {
+			lines = append(lines, f.Line)
+		}
+		sort.Ints(lines)
+		assert.Equal(t, []int{2, 3, 4, 6}, lines)
+		for _, f := range summary.Failures {
+			switch f.Line {
+			case 3, 6:
+				assert.True(t, errors.Is(f.Err, ksql.ErrInvalidRow), "line %d: %v", f.Line, f.Err)
+			case 4:
+				assert.True(t, errors.Is(f.Err, ksql.ErrAckUnsucessful), "line %d: %v", f.Line, f.Err)
+			}
+		}
+		assert.Equal(t, []string{"a", "c"}, srv.ids())
+	})
+
+	t.Run("it should fail when the format is unknown", func(t *testing.T) {
+		srv := newFakeServer(t)
+		defer srv.Close()
+		_, err := Load(context.Background(), srv.client(), strings.NewReader(""), Config{Target: "orders", Format: "xml"})
+		assert.True(t, errors.Is(err, ErrUnknownFormat))
+	})
+}
+
+func TestCSVSource(t *testing.T) {
+	types := newColumnTypes(description)
+	t.Run("it should report the line of rows which can't be coerced", func(t *testing.T) {
+		src, err := newCSVSource(strings.NewReader("id,quantity,paid\na,1,true\nb,x,true\nc,3,maybe\n"), nil, types)
+		assert.NoError(t, err)
+		var lines []int
+		for {
+			rec, err := src.next()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err)
+			if rec.err != nil {
+				lines = append(lines, rec.line)
+			}
+		}
+		assert.Equal(t, []int{3, 4}, lines)
+	})
+	t.Run("it should report the line of rows which can't be parsed", func(t *testing.T) {
+		src, err := newCSVSource(strings.NewReader("id,quantity\na,1\n\"\nb,2\n"), nil, types)
+		assert.NoError(t, err)
+		rec, err := src.next()
+		assert.NoError(t, err)
+		assert.NoError(t, rec.err)
+		rec, err = src.next()
+		assert.NoError(t, err)
+		var parseErr *csv.ParseError
+		assert.True(t, errors.As(rec.err, &parseErr))
+		assert.Equal(t, 3, rec.line)
+	})
+	t.Run("it should keep empty strings for STRING columns", func(t *testing.T) {
+		src, err := newCSVSource(strings.NewReader("id,quantity\n,\n"), nil, types)
+		assert.NoError(t, err)
+		rec, err := src.next()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"id": "", "quantity": nil}, rec.row)
+	})
+}
+
+func TestFormatFromPath(t *testing.T) {
+	for path, want := range map[string]Format{"a.csv": CSV, "a.ndjson": NDJSON, "a.jsonl": NDJSON} {
+		got, err := FormatFromPath(path)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+	_, err := FormatFromPath("a.xml")
+	assert.True(t, errors.Is(err, ErrUnknownFormat))
+}
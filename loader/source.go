@@ -0,0 +1,204 @@
+package loader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	ksql "github.com/vancelongwill/ksql-go/client"
+)
+
+// Format is the format of the file being loaded
+type Format string
+
+const (
+	// CSV is a comma separated file with a header row naming the columns
+	CSV Format = "csv"
+	// NDJSON is a newline delimited JSON file with one object per row
+	NDJSON Format = "ndjson"
+)
+
+// ErrUnknownFormat is returned when the format of the file isn't supported
+var ErrUnknownFormat = errors.New("unknown file format")
+
+// FormatFromPath infers the format of a file from its extension
+func FormatFromPath(path string) (Format, error) {
+	switch {
+	case strings.HasSuffix(path, ".csv"):
+		return CSV, nil
+	case strings.HasSuffix(path, ".ndjson"), strings.HasSuffix(path, ".jsonl"), strings.HasSuffix(path, ".json"):
+		return NDJSON, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownFormat, path)
+}
+
+// record is a single row read from the source file
+type record struct {
+	line int
+	row  map[string]interface{}
+	// err is set when the line couldn't be parsed, in which case the row is skipped
+	err error
+}
+
+// source reads rows from a file
+type source interface {
+	// next returns the next record, or io.EOF once the file has been read
+	next() (record, error)
+}
+
+// columnTypes maps upper-cased column names to their ksqlDB types
+type columnTypes map[string]ksql.Schema
+
+func newColumnTypes(desc ksql.SourceDescription) columnTypes {
+	types := make(columnTypes, len(desc.Fields))
+	for _, f := range desc.Fields {
+		types[strings.ToUpper(f.Name)] = f.Schema
+	}
+	return types
+}
+
+// mapper renames source fields to column names, dropping fields mapped to "-"
+type mapper map[string]string
+
+func (m mapper) column(field string) (string, bool) {
+	if col, ok := m[field]; ok {
+		return col, col != "-"
+	}
+	return field, true
+}
+
+// csvSource reads a CSV file with a header row, coercing each value to the type of its column
+type csvSource struct {
+	r       *csv.Reader
+	columns []string
+	types   columnTypes
+}
+
+func newCSVSource(r io.Reader, columns mapper, types columnTypes) (*csvSource, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the CSV header: %w", err)
+	}
+	s := &csvSource{r: cr, columns: make([]string, len(header)), types: types}
+	for i, field := range header {
+		if col, ok := columns.column(strings.TrimSpace(field)); ok {
+			s.columns[i] = col
+		}
+	}
+	return s, nil
+}
+
+func (s *csvSource) next() (record, error) {
+	values, err := s.r.Read()
+	if err == io.EOF {
+		return record{}, io.EOF
+	}
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return record{line: parseErr.StartLine, err: err}, nil
+		}
+		return record{}, err
+	}
+	line, _ := s.r.FieldPos(0)
+	rec := record{line: line, row: make(map[string]interface{}, len(values))}
+	for i, value := range values {
+		if i >= len(s.columns) || s.columns[i] == "" {
+			continue
+		}
+		col := s.columns[i]
+		v, err := coerce(value, s.types[strings.ToUpper(col)])
+		if err != nil {
+			rec.err = fmt.Errorf("column %s: %w", col, err)
+			return rec, nil
+		}
+		rec.row[col] = v
+	}
+	return rec, nil
+}
+
+// coerce converts a CSV value to the type of its column. Empty values are NULL, except for STRING columns.
+func coerce(value string, schema ksql.Schema) (interface{}, error) {
+	typ := strings.ToUpper(schema.Type)
+	if value == "" && typ != "STRING" {
+		return nil, nil
+	}
+	switch typ {
+	case "BOOLEAN":
+		return strconv.ParseBool(strings.TrimSpace(value))
+	case "INTEGER", "BIGINT", "DOUBLE", "DECIMAL":
+		n := json.Number(strings.TrimSpace(value))
+		if _, err := n.Float64(); err != nil {
+			return nil, fmt.Errorf("%q is not a valid %s", value, typ)
+		}
+		return n, nil
+	case "BYTES":
+		return base64.StdEncoding.DecodeString(value)
+	case "ARRAY", "MAP", "STRUCT":
+		return decodeJSON([]byte(value))
+	}
+	// strings, times and unknown columns are sent as they are
+	return value, nil
+}
+
+// ndjsonSource reads a file with a JSON object on each line
+type ndjsonSource struct {
+	r       *bufio.Reader
+	line    int
+	columns mapper
+}
+
+func newNDJSONSource(r io.Reader, columns mapper) *ndjsonSource {
+	return &ndjsonSource{r: bufio.NewReader(r), columns: columns}
+}
+
+func (s *ndjsonSource) next() (record, error) {
+	for {
+		b, err := s.r.ReadBytes('\n')
+		if len(b) == 0 && err != nil {
+			return record{}, err
+		}
+		s.line++
+		b = bytes.TrimSpace(b)
+		if len(b) == 0 {
+			continue
+		}
+		rec := record{line: s.line}
+		v, decodeErr := decodeJSON(b)
+		obj, ok := v.(map[string]interface{})
+		switch {
+		case decodeErr != nil:
+			rec.err = decodeErr
+		case !ok:
+			rec.err = fmt.Errorf("expected a JSON object but got %T", v)
+		default:
+			rec.row = make(map[string]interface{}, len(obj))
+			for field, value := range obj {
+				if col, ok := s.columns.column(field); ok {
+					rec.row[col] = value
+				}
+			}
+		}
+		return rec, nil
+	}
+}
+
+// decodeJSON decodes a JSON value, keeping numbers as json.Number so that large integers and decimals don't lose precision
+func decodeJSON(b []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
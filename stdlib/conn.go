@@ -5,6 +5,8 @@ import (
 	"database/sql/driver"
 	"errors"
 	"strconv"
+	"sync"
+	"time"
 
 	ksql "github.com/vancelongwill/ksql-go/client"
 )
@@ -19,11 +21,30 @@ var (
 
 //go:generate mockgen -source ../client/client.go -destination mocks/client.go -package mocks
 
-// Conn provides the driver.Conn interface for interacting with the ksqlDB client
+// connConfig holds the defaults applied to the statements and queries of a connection
+type connConfig struct {
+	queryStrategy     ksql.QueryStrategy
+	streamsProperties ksql.StreamsProperties
+	timeout           time.Duration
+	// insertsStream routes single row INSERT INTO ... VALUES statements through an inserts stream
+	insertsStream bool
+}
+
+// Conn provides the driver.Conn interface for interacting with the ksqlDB client.
+//
+// The client may be shared with other connections, so a connection only tracks and closes the query streams and inserts streams it opened itself.
 type Conn struct {
 	client             ksql.Client
+	config             connConfig
+	inserts            *insertsPool
 	preparedStatements map[string]PreparedStatement
 	stmtNameCounter    int
+	// ownsClient is set when the connection created its client, which it closes with the connection
+	ownsClient bool
+
+	mu sync.Mutex
+	// streams holds the query streams opened by the connection which haven't been closed yet
+	streams map[*rowWrapper]struct{}
 }
 
 // Prepare a SQL query. Note that there are no optimizations here and this method is only provided for compatibility reasons.
@@ -31,9 +52,38 @@ func (c *Conn) Prepare(query string) (driver.Stmt, error) {
 	return c.PrepareContext(context.Background(), query)
 }
 
-// Close the underlying client connection
+// Close the query streams and inserts streams opened by the connection, returning the first error. The client is left open, as other connections may share it.
 func (c *Conn) Close() error {
-	return c.client.Close()
+	c.mu.Lock()
+	streams := c.streams
+	c.streams = map[*rowWrapper]struct{}{}
+	c.mu.Unlock()
+	firstErr := c.inserts.Close()
+	for rows := range streams {
+		if err := rows.rows.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if c.ownsClient {
+		if err := c.client.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// track records an open query stream until it is closed by either the rows or the connection
+func (c *Conn) track(rows ksql.Rows) *rowWrapper {
+	w := &rowWrapper{rows: rows}
+	w.release = func() {
+		c.mu.Lock()
+		delete(c.streams, w)
+		c.mu.Unlock()
+	}
+	c.mu.Lock()
+	c.streams[w] = struct{}{}
+	c.mu.Unlock()
+	return w
 }
 
 // Begin is not supported but implemented here for compatibility
@@ -53,6 +103,8 @@ func (c *Conn) Driver() driver.Driver {
 
 // Ping checks the status of the ksqlDB cluster using the /info endpoint
 func (c *Conn) Ping(ctx context.Context) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
 	_, err := c.client.Info(ctx)
 	return err
 }
@@ -67,32 +119,39 @@ func (c *Conn) IsValid() bool {
 	return true
 }
 
-type execResult struct{}
-
-// LastInsertId is a placeholder for compatibility
-func (e *execResult) LastInsertId() (int64, error) {
-	return 0, nil
-}
-
-// RowsAffected is a placeholder for compatibility
-func (e *execResult) RowsAffected() (int64, error) {
-	return 0, nil
-}
-
-// ExecContext executes any arbitrary ksql statements, except queries
+// ExecContext executes any arbitrary ksql statements, except queries, returning a *Result.
+//
+// When the connection is configured to use inserts streams, a single row INSERT INTO ... VALUES statement into a stream, which lists its columns and only has literal values, is written to an inserts stream instead and returns once the row is acknowledged.
 func (c *Conn) ExecContext(ctx context.Context, stmt string, args []driver.NamedValue) (driver.Result, error) {
 	sql, err := buildStatement(stmt, args)
 	if err != nil {
 		return nil, err
 	}
-	_, err = c.client.Exec(ctx, ksql.ExecPayload{
+	props := loadStreamsProperties(args)
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	if c.config.insertsStream && props == nil {
+		if ins, ok := parseInsertValues(sql); ok {
+			inserted, err := c.inserts.insert(ctx, ins)
+			if err != nil {
+				return nil, err
+			}
+			if inserted {
+				return newResult(nil), nil
+			}
+		}
+	}
+	if props == nil {
+		props = c.config.streamsProperties
+	}
+	results, err := c.client.Exec(ctx, ksql.ExecPayload{
 		KSQL:              sql,
-		StreamsProperties: loadStreamsProperties(args),
+		StreamsProperties: props,
 	})
 	if err != nil {
 		return nil, err
 	}
-	return &execResult{}, nil
+	return newResult(results), nil
 }
 
 func loadStreamsProperties(args []driver.NamedValue) ksql.StreamsProperties {
@@ -107,11 +166,8 @@ func loadStreamsProperties(args []driver.NamedValue) ksql.StreamsProperties {
 	return props
 }
 
-func parseQueryArgs(args []driver.NamedValue) (*ksql.QueryConfig, []driver.NamedValue) {
-	var (
-		config       = ksql.DefaultQueryConfig
-		filteredArgs []driver.NamedValue
-	)
+func parseQueryArgs(args []driver.NamedValue, config *ksql.QueryConfig) (*ksql.QueryConfig, []driver.NamedValue) {
+	var filteredArgs []driver.NamedValue
 	for _, arg := range args {
 		switch v := arg.Value.(type) {
 		case *ksql.QueryConfig:
@@ -126,7 +182,7 @@ func parseQueryArgs(args []driver.NamedValue) (*ksql.QueryConfig, []driver.Named
 
 // QueryContext runs a SELECT query via the ksqlDB client
 func (c *Conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
-	conf, args := parseQueryArgs(args)
+	conf, args := parseQueryArgs(args, c.defaultQueryConfig())
 	q, err := buildStatement(query, args)
 	if err != nil {
 		return nil, err
@@ -137,18 +193,47 @@ func (c *Conn) QueryContext(ctx context.Context, query string, args []driver.Nam
 			KSQL:       q,
 			Properties: conf.StreamsProperties,
 		})
-		return &rowWrapper{rows}, err
+		if err != nil {
+			return nil, err
+		}
+		return c.track(rows), nil
 	case ksql.StaticQuery:
+		// static queries read all their rows before returning, so the timeout doesn't outlive them
+		ctx, cancel := c.withTimeout(ctx)
+		defer cancel()
 		rows, err := c.client.Query(ctx, ksql.QueryPayload{
 			KSQL:              q,
 			StreamsProperties: conf.StreamsProperties,
 		})
-		return &rowWrapper{rows}, err
+		return &rowWrapper{rows: rows}, err
 
 	}
 	return nil, ErrInvalidQueryStrategy
 }
 
+// defaultQueryConfig returns the query config used when a query has no QueryConfig argument
+func (c *Conn) defaultQueryConfig() *ksql.QueryConfig {
+	if c.config.queryStrategy == "" && c.config.streamsProperties == nil {
+		return ksql.DefaultQueryConfig
+	}
+	conf := ksql.NewQueryConfig()
+	if c.config.queryStrategy != "" {
+		conf.Strategy = c.config.queryStrategy
+	}
+	if c.config.streamsProperties != nil {
+		conf.StreamsProperties = c.config.streamsProperties
+	}
+	return conf
+}
+
+// withTimeout limits the context by the configured timeout, if any
+func (c *Conn) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.config.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.config.timeout)
+}
+
 // Client exposes the underlying ksql client instance
 func (c *Conn) Client() ksql.Client {
 	return c.client
@@ -180,7 +265,9 @@ func (c *Conn) CheckNamedValue(val *driver.NamedValue) error {
 func newConn(client ksql.Client) *Conn {
 	return &Conn{
 		client:             client,
+		inserts:            newInsertsPool(client),
 		preparedStatements: map[string]PreparedStatement{},
+		streams:            map[*rowWrapper]struct{}{},
 	}
 }
 
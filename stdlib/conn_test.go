@@ -3,12 +3,19 @@ package stdlib
 import (
 	"context"
 	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	ksql "github.com/vancelongwill/ksql-go/client"
 	"github.com/vancelongwill/ksql-go/stdlib/mocks"
+	"golang.org/x/net/http2"
 )
 
 func TestConn(t *testing.T) {
@@ -59,14 +66,15 @@ func TestConn(t *testing.T) {
 			query := "select something from somewhere where prop = $1;"
 			nv := []driver.NamedValue{
 				{
-					Name:  "",
-					Value: 1,
+					Name:    "",
+					Ordinal: 1,
+					Value:   1,
 				},
 			}
 			ctx := context.Background()
 			mockClient.EXPECT().
 				Query(ctx, ksql.QueryPayload{
-					KSQL:              query,
+					KSQL:              "select something from somewhere where prop = 1;",
 					StreamsProperties: ksql.StreamsProperties{},
 				}).
 				Return(nil, nil)
@@ -82,8 +90,9 @@ func TestConn(t *testing.T) {
 			query := "select something from somewhere where prop = $1;"
 			nv := []driver.NamedValue{
 				{
-					Name:  "",
-					Value: 1,
+					Name:    "",
+					Ordinal: 1,
+					Value:   1,
 				},
 				{
 					Name: "",
@@ -95,7 +104,7 @@ func TestConn(t *testing.T) {
 			ctx := context.Background()
 			mockClient.EXPECT().
 				QueryStream(ctx, ksql.QueryStreamPayload{
-					KSQL:       query,
+					KSQL:       "select something from somewhere where prop = 1;",
 					Properties: nil,
 				}).
 				Return(nil, nil)
@@ -111,8 +120,9 @@ func TestConn(t *testing.T) {
 			query := "select something from somewhere where prop = $1;"
 			nv := []driver.NamedValue{
 				{
-					Name:  "",
-					Value: 1,
+					Name:    "",
+					Ordinal: 1,
+					Value:   1,
 				},
 				{
 					Name: "",
@@ -124,13 +134,31 @@ func TestConn(t *testing.T) {
 			ctx := context.Background()
 			mockClient.EXPECT().
 				Query(ctx, ksql.QueryPayload{
-					KSQL:              query,
+					KSQL:              "select something from somewhere where prop = 1;",
 					StreamsProperties: nil,
 				}).
 				Return(nil, nil)
 			_, err := c.QueryContext(ctx, query, nv)
 			assert.NoError(t, err)
 		})
+
+		t.Run("when the connection has a default strategy and properties", func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockClient := mocks.NewMockClient(ctrl)
+			c := newConn(mockClient)
+			props := ksql.NewStreamsProperties(ksql.OffsetEarliest)
+			c.config = connConfig{queryStrategy: ksql.StreamQuery, streamsProperties: props}
+			ctx := context.Background()
+			mockClient.EXPECT().
+				QueryStream(ctx, ksql.QueryStreamPayload{
+					KSQL:       "select something from somewhere;",
+					Properties: props,
+				}).
+				Return(nil, nil)
+			_, err := c.QueryContext(ctx, "select something from somewhere;", nil)
+			assert.NoError(t, err)
+		})
 	})
 
 	t.Run("ExecContext", func(t *testing.T) {
@@ -185,5 +213,145 @@ func TestConn(t *testing.T) {
 			_, err := c.ExecContext(ctx, query, nv)
 			assert.NoError(t, err)
 		})
+		t.Run("when the connection has default properties and a timeout", func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockClient := mocks.NewMockClient(ctrl)
+			c := newConn(mockClient)
+			props := ksql.NewStreamsProperties(ksql.OffsetEarliest)
+			c.config = connConfig{streamsProperties: props, timeout: time.Minute}
+			mockClient.EXPECT().
+				Exec(gomock.Any(), ksql.ExecPayload{
+					KSQL:              "DROP STREAM s1;",
+					StreamsProperties: props,
+				}).
+				DoAndReturn(func(ctx context.Context, _ ksql.ExecPayload) ([]ksql.ExecResult, error) {
+					_, ok := ctx.Deadline()
+					assert.True(t, ok)
+					return nil, nil
+				})
+			_, err := c.ExecContext(context.Background(), "DROP STREAM s1;", nil)
+			assert.NoError(t, err)
+		})
+	})
+}
+
+// streamsServer serves push queries which stay open until they are closed, recording the closed query IDs
+type streamsServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	queries int
+	closed  []string
+}
+
+func newStreamsServer(t *testing.T) *streamsServer {
+	s := &streamsServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/query-stream", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.queries++
+		id := fmt.Sprintf("q%d", s.queries)
+		s.mu.Unlock()
+		assert.NoError(t, json.NewEncoder(w).Encode(ksql.QueryResultHeader{
+			QueryID:     id,
+			ColumnNames: []string{"K"},
+			ColumnTypes: []string{"STRING"},
+		}))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	mux.HandleFunc("/close-query", func(w http.ResponseWriter, r *http.Request) {
+		var payload ksql.CloseQueryPayload
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		s.mu.Lock()
+		s.closed = append(s.closed, payload.QueryID)
+		s.mu.Unlock()
+	})
+	s.Server = httptest.NewUnstartedServer(mux)
+	s.EnableHTTP2 = true
+	s.StartTLS()
+	return s
+}
+
+func (s *streamsServer) connector() *Connector {
+	tr := &http.Transport{}
+	if err := http2.ConfigureTransport(tr); err != nil {
+		panic(err)
+	}
+	tr.TLSClientConfig.InsecureSkipVerify = true
+	return NewConnector(ksql.New(s.URL, ksql.WithHTTPClient(&http.Client{Transport: tr})))
+}
+
+func (s *streamsServer) closedQueries() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.closed...)
+}
+
+func TestConnClose(t *testing.T) {
+	streamQuery := []driver.NamedValue{{Ordinal: 1, Value: &ksql.QueryConfig{Strategy: ksql.StreamQuery}}}
+
+	t.Run("it should only close the streams opened by the connection", func(t *testing.T) {
+		srv := newStreamsServer(t)
+		defer srv.Close()
+		connector := srv.connector()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		c1, err := connector.Connect(ctx)
+		assert.NoError(t, err)
+		c2, err := connector.Connect(ctx)
+		assert.NoError(t, err)
+		rows1, err := c1.(*Conn).QueryContext(ctx, "SELECT * FROM s1 EMIT CHANGES;", streamQuery)
+		assert.NoError(t, err)
+		rows2, err := c2.(*Conn).QueryContext(ctx, "SELECT * FROM s1 EMIT CHANGES;", streamQuery)
+		assert.NoError(t, err)
+
+		assert.NoError(t, c1.Close())
+		assert.Equal(t, []string{"q1"}, srv.closedQueries())
+		assert.Equal(t, ksql.ErrRowsClosed, rows1.Next(make([]driver.Value, 1)))
+		assert.Len(t, c2.(*Conn).streams, 1)
+
+		assert.NoError(t, rows2.Close())
+		assert.Empty(t, c2.(*Conn).streams)
+		assert.NoError(t, c2.Close())
+		assert.Equal(t, []string{"q1", "q2"}, srv.closedQueries())
+	})
+
+	t.Run("it should not close the client", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		// the mock fails the test if Close is called on the client
+		mockClient := mocks.NewMockClient(ctrl)
+		c := newConn(mockClient)
+		stmt, err := c.PrepareContext(context.Background(), "SELECT * FROM t1;")
+		assert.NoError(t, err)
+		assert.NoError(t, stmt.Close())
+		assert.NoError(t, c.Close())
+		assert.NoError(t, NewConnector(mockClient).Close())
+	})
+
+	t.Run("it should close a client it owns", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockClient := mocks.NewMockClient(ctrl)
+		mockClient.EXPECT().Close().Return(nil).Times(2)
+		c := newConn(mockClient)
+		c.ownsClient = true
+		assert.NoError(t, c.Close())
+		connector := &Connector{client: mockClient, ownsClient: true}
+		assert.NoError(t, connector.Close())
+	})
+
+	t.Run("it should own the clients created from a DSN", func(t *testing.T) {
+		conn, err := (&Driver{}).Open("http://host1:8088")
+		assert.NoError(t, err)
+		assert.True(t, conn.(*Conn).ownsClient)
+		assert.NoError(t, conn.Close())
+		connector, err := NewConnectorFromDSN("http://host1:8088")
+		assert.NoError(t, err)
+		assert.True(t, connector.ownsClient)
+		assert.NoError(t, connector.Close())
 	})
 }
@@ -3,6 +3,7 @@ package stdlib
 import (
 	"context"
 	"database/sql/driver"
+	"io"
 
 	ksql "github.com/vancelongwill/ksql-go/client"
 )
@@ -10,11 +11,16 @@ import (
 // Connector implements the database/sql/driver package's Connector interface
 type Connector struct {
 	client ksql.Client
+	config connConfig
+	// ownsClient is set when the connector created its client from a DSN, which it closes with the connector
+	ownsClient bool
 }
 
 // Connect returns a new connection with access to the client
 func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
-	return newConn(c.client), nil
+	conn := newConn(c.client)
+	conn.config = c.config
+	return conn, nil
 }
 
 // Driver returns a new driver instance
@@ -27,9 +33,49 @@ func (c *Connector) Client() ksql.Client {
 	return c.client
 }
 
+// Close closes the client if the connector created it from a DSN. It is called by sql.DB.Close.
+func (c *Connector) Close() error {
+	if !c.ownsClient {
+		return nil
+	}
+	return c.client.Close()
+}
+
+// ConnectorOption configures the connections of a Connector
+type ConnectorOption func(*connConfig)
+
+// WithInsertsStream routes each single row INSERT INTO ... VALUES statement into a stream through an inserts stream, rather than executing it as a statement, which is much faster.
+//
+// Only statements listing their columns, with literal values and unquoted identifiers, are routed. Each connection opens an inserts stream per target on first use, and keeps it until the connection is closed.
+func WithInsertsStream() ConnectorOption {
+	return func(c *connConfig) {
+		c.insertsStream = true
+	}
+}
+
 // NewConnector allows a specific client to be passed to database/sql compatible connectors
-func NewConnector(client ksql.Client) *Connector {
-	return &Connector{client}
+func NewConnector(client ksql.Client, options ...ConnectorOption) *Connector {
+	c := &Connector{client: client}
+	for _, opt := range options {
+		opt(&c.config)
+	}
+	return c
+}
+
+// NewConnectorFromDSN parses the data source name, and returns a connector with a client configured by it. See DSN for the format.
+func NewConnectorFromDSN(dsn string) (*Connector, error) {
+	d, err := ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &Connector{
+		client:     ksql.New(d.URLs[0], d.ClientOptions()...),
+		config:     d.connConfig(),
+		ownsClient: true,
+	}, nil
 }
 
-var _ driver.Connector = &Connector{}
+var (
+	_ driver.Connector = &Connector{}
+	_ io.Closer        = &Connector{}
+)
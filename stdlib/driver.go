@@ -1,22 +1,25 @@
 package stdlib
 
-import (
-	"database/sql/driver"
-
-	ksql "github.com/vancelongwill/ksql-go/client"
-)
+import "database/sql/driver"
 
 // Driver is a database/sql compatible driver
 type Driver struct{}
 
-// Open returns a new connection with a new client
-func (d *Driver) Open(url string) (driver.Conn, error) {
-	return newConn(ksql.New(url)), nil
+// Open returns a new connection with its own client configured by the data source name, which is closed with the connection. See DSN for the format.
+func (d *Driver) Open(dsn string) (driver.Conn, error) {
+	c, err := NewConnectorFromDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	conn := newConn(c.client)
+	conn.config = c.config
+	conn.ownsClient = true
+	return conn, nil
 }
 
-// OpenConnector returns a new connection with a new client
-func (d *Driver) OpenConnector(url string) (driver.Connector, error) {
-	return newConn(ksql.New(url)), nil
+// OpenConnector returns a connector with a new client configured by the data source name. See DSN for the format.
+func (d *Driver) OpenConnector(dsn string) (driver.Connector, error) {
+	return NewConnectorFromDSN(dsn)
 }
 
 var _ driver.Driver = &Driver{}
@@ -0,0 +1,209 @@
+package stdlib
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	ksql "github.com/vancelongwill/ksql-go/client"
+)
+
+// ErrInvalidDSN is returned when a data source name can't be parsed
+var ErrInvalidDSN = errors.New("invalid DSN")
+
+// propertyPrefix prefixes the DSN parameters holding default streams properties
+const propertyPrefix = "property."
+
+// DSN is a parsed data source name, a URL with one or more comma separated hosts and optional query parameters
+type DSN struct {
+	// URLs holds the URL of each host, in order
+	URLs []string
+	// Username and Password are the basic auth credentials
+	Username string
+	Password string
+	// TLS holds the TLS configuration, or nil for plain HTTP2
+	TLS *tls.Config
+	// QueryStrategy is the query strategy used when a query has no QueryConfig argument
+	QueryStrategy ksql.QueryStrategy
+	// StreamsProperties are the streams properties used when a statement or query has none of its own
+	StreamsProperties ksql.StreamsProperties
+	// Timeout limits statements, pings and static queries when their context has no earlier deadline. Zero means no limit.
+	Timeout time.Duration
+	// DialTimeout limits connecting to a server. Zero means no limit.
+	DialTimeout time.Duration
+	// InsertsStream routes single row INSERT INTO ... VALUES statements through inserts streams. See WithInsertsStream.
+	InsertsStream bool
+}
+
+// ParseDSN parses a data source name, loading any TLS files it refers to
+func ParseDSN(dsn string) (*DSN, error) {
+	scheme, rest, ok := strings.Cut(dsn, "://")
+	if !ok || (scheme != "http" && scheme != "https") {
+		return nil, fmt.Errorf("%w: expected an http or https URL", ErrInvalidDSN)
+	}
+	authority, path := rest, ""
+	if i := strings.IndexAny(rest, "/?"); i >= 0 {
+		authority, path = rest[:i], rest[i:]
+	}
+	d := &DSN{
+		QueryStrategy:     ksql.StaticQuery,
+		StreamsProperties: ksql.StreamsProperties{},
+	}
+	if i := strings.LastIndex(authority, "@"); i >= 0 {
+		user, err := url.Parse("http://" + authority[:i+1] + "host")
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidDSN, err)
+		}
+		d.Username = user.User.Username()
+		d.Password, _ = user.User.Password()
+		authority = authority[i+1:]
+	}
+
+	var query url.Values
+	for _, host := range strings.Split(authority, ",") {
+		u, err := url.Parse(scheme + "://" + host + path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidDSN, err)
+		}
+		if u.Host == "" {
+			return nil, fmt.Errorf("%w: empty host", ErrInvalidDSN)
+		}
+		query = u.Query()
+		u.RawQuery = ""
+		d.URLs = append(d.URLs, u.String())
+	}
+	if err := d.parseParams(scheme, query); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDSN, err)
+	}
+	if d.TLS != nil && scheme == "http" {
+		// a TLS parameter switches an http URL to https, as the TLS client can't make plain HTTP requests
+		for i, u := range d.URLs {
+			d.URLs[i] = "https" + strings.TrimPrefix(u, "http")
+		}
+	}
+	return d, nil
+}
+
+func (d *DSN) parseParams(scheme string, query url.Values) error {
+	var (
+		useTLS   = scheme == "https"
+		tlsConf  = &tls.Config{}
+		certFile string
+		keyFile  string
+		err      error
+	)
+	for name, values := range query {
+		value := values[len(values)-1]
+		switch {
+		case name == "user":
+			d.Username = value
+		case name == "password":
+			d.Password = value
+		case name == "tls_ca":
+			useTLS = true
+			pem, err := os.ReadFile(value)
+			if err != nil {
+				return err
+			}
+			tlsConf.RootCAs = x509.NewCertPool()
+			if !tlsConf.RootCAs.AppendCertsFromPEM(pem) {
+				return fmt.Errorf("no certificates found in %s", value)
+			}
+		case name == "tls_cert":
+			useTLS = true
+			certFile = value
+		case name == "tls_key":
+			useTLS = true
+			keyFile = value
+		case name == "tls_insecure":
+			useTLS = true
+			if tlsConf.InsecureSkipVerify, err = strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("invalid tls_insecure %q", value)
+			}
+		case name == "query_strategy":
+			switch strings.ToLower(value) {
+			case "stream":
+				d.QueryStrategy = ksql.StreamQuery
+			case "static":
+				d.QueryStrategy = ksql.StaticQuery
+			default:
+				return fmt.Errorf("%w %q, expected stream or static", ErrInvalidQueryStrategy, value)
+			}
+		case name == "timeout":
+			if d.Timeout, err = parseTimeout(value); err != nil {
+				return fmt.Errorf("invalid timeout %q", value)
+			}
+		case name == "dial_timeout":
+			if d.DialTimeout, err = parseTimeout(value); err != nil {
+				return fmt.Errorf("invalid dial_timeout %q", value)
+			}
+		case name == "inserts_stream":
+			if d.InsertsStream, err = strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("invalid inserts_stream %q", value)
+			}
+		case strings.HasPrefix(name, propertyPrefix) && len(name) > len(propertyPrefix):
+			d.StreamsProperties[strings.TrimPrefix(name, propertyPrefix)] = value
+		default:
+			return fmt.Errorf("unknown parameter %q", name)
+		}
+	}
+	if (certFile == "") != (keyFile == "") {
+		return errors.New("tls_cert and tls_key must be set together")
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		tlsConf.Certificates = []tls.Certificate{cert}
+	}
+	if useTLS {
+		d.TLS = tlsConf
+	}
+	return nil
+}
+
+func parseTimeout(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, ErrInvalidDSN
+	}
+	return d, nil
+}
+
+// ClientOptions returns the options for a client connecting to the first host, which decodes numbers as json.Number so that the driver returns them exactly
+func (d *DSN) ClientOptions() []ksql.Option {
+	options := []ksql.Option{ksql.WithUseNumber()}
+	if len(d.URLs) > 1 {
+		options = append(options, ksql.WithFailover(d.URLs[1:]...))
+	}
+	if d.Username != "" || d.Password != "" {
+		options = append(options, ksql.WithBasicAuth(d.Username, d.Password))
+	}
+	if d.TLS != nil {
+		options = append(options, ksql.WithTLSConfig(d.TLS))
+	}
+	if d.DialTimeout > 0 {
+		options = append(options, ksql.WithDialTimeout(d.DialTimeout))
+	}
+	return options
+}
+
+// connConfig holds the defaults of a connection
+func (d *DSN) connConfig() connConfig {
+	conf := connConfig{
+		queryStrategy: d.QueryStrategy,
+		timeout:       d.Timeout,
+		insertsStream: d.InsertsStream,
+	}
+	if len(d.StreamsProperties) > 0 {
+		conf.streamsProperties = d.StreamsProperties
+	}
+	return conf
+}
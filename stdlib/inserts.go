@@ -0,0 +1,266 @@
+package stdlib
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+
+	ksql "github.com/vancelongwill/ksql-go/client"
+	"github.com/vancelongwill/ksql-go/ksqltypes"
+	"github.com/vancelongwill/ksql-go/lexer"
+)
+
+// insertValues is a parsed single row INSERT INTO ... VALUES statement
+type insertValues struct {
+	target string
+	row    map[string]interface{}
+}
+
+// parseInsertValues parses an INSERT INTO ... VALUES statement which lists its columns and has only literal values, reporting false for any other statement.
+//
+// Quoted identifiers aren't supported, as their case would be lost by the inserts stream.
+func parseInsertValues(sql string) (insertValues, bool) {
+	tokens, err := lexer.Tokenize(sql)
+	if err != nil {
+		return insertValues{}, false
+	}
+	tokens = lexer.Significant(tokens)
+	if n := len(tokens); n > 0 && tokens[n-1].Kind == lexer.Semicolon {
+		tokens = tokens[:n-1]
+	}
+	p := &insertParser{tokens: tokens}
+	if !p.keyword("INSERT") || !p.keyword("INTO") {
+		return insertValues{}, false
+	}
+	target, ok := p.identifier()
+	if !ok || !p.operator("(") {
+		return insertValues{}, false
+	}
+	var columns []string
+	for {
+		col, ok := p.identifier()
+		if !ok {
+			return insertValues{}, false
+		}
+		columns = append(columns, col)
+		if p.operator(")") {
+			break
+		}
+		if !p.operator(",") {
+			return insertValues{}, false
+		}
+	}
+	if !p.keyword("VALUES") || !p.operator("(") {
+		return insertValues{}, false
+	}
+	row := make(map[string]interface{}, len(columns))
+	for i, col := range columns {
+		if i > 0 && !p.operator(",") {
+			return insertValues{}, false
+		}
+		v, ok := p.literal()
+		if _, dup := row[col]; !ok || dup {
+			return insertValues{}, false
+		}
+		row[col] = v
+	}
+	if !p.operator(")") || p.pos != len(p.tokens) {
+		return insertValues{}, false
+	}
+	return insertValues{target: target, row: row}, true
+}
+
+// insertParser consumes significant tokens
+type insertParser struct {
+	tokens []lexer.Token
+	pos    int
+}
+
+func (p *insertParser) peek() lexer.Token {
+	if p.pos >= len(p.tokens) {
+		return lexer.Token{Kind: lexer.EOF}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *insertParser) keyword(kw string) bool {
+	if p.peek().IsKeyword(kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *insertParser) operator(op string) bool {
+	if tok := p.peek(); tok.Kind == lexer.Operator && tok.Text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// identifier consumes an unquoted identifier, returning it upper-cased as ksqlDB does
+func (p *insertParser) identifier() (string, bool) {
+	tok := p.peek()
+	if tok.Kind != lexer.Identifier {
+		return "", false
+	}
+	p.pos++
+	return strings.ToUpper(tok.Text), true
+}
+
+// literal consumes a string, number, boolean or NULL literal, returning its JSON value
+func (p *insertParser) literal() (interface{}, bool) {
+	if p.operator("(") {
+		// negative numbers are parenthesised when rendered from bound arguments, as in (-1)
+		if !p.operator("-") {
+			return nil, false
+		}
+		n, ok := p.number("-")
+		return n, ok && p.operator(")")
+	}
+	if p.operator("-") {
+		return p.number("-")
+	}
+	if n, ok := p.number(""); ok {
+		return n, true
+	}
+	tok := p.peek()
+	p.pos++
+	switch {
+	case tok.Kind == lexer.String:
+		return tok.Value(), true
+	case tok.IsKeyword("TRUE"):
+		return true, true
+	case tok.IsKeyword("FALSE"):
+		return false, true
+	case tok.IsKeyword("NULL"):
+		return nil, true
+	}
+	return nil, false
+}
+
+// number consumes a number literal with the given sign, which must also be a valid JSON number
+func (p *insertParser) number(sign string) (json.Number, bool) {
+	tok := p.peek()
+	if tok.Kind != lexer.Number || !ksqltypes.IsJSONNumber(sign+tok.Text) {
+		return "", false
+	}
+	p.pos++
+	return json.Number(sign + tok.Text), true
+}
+
+// insertsPool holds an inserts stream for each target of a connection, opened on first use and kept until the connection is closed
+type insertsPool struct {
+	client ksql.Client
+
+	mu      sync.Mutex
+	writers map[string]*ksql.InsertsStreamWriter
+	// tables holds the targets which aren't streams, whose inserts are executed as statements
+	tables map[string]bool
+}
+
+func newInsertsPool(client ksql.Client) *insertsPool {
+	return &insertsPool{
+		client:  client,
+		writers: make(map[string]*ksql.InsertsStreamWriter),
+		tables:  make(map[string]bool),
+	}
+}
+
+// insert writes the row to the inserts stream of its target and waits for the ack. It reports false if the target isn't a stream, in which case nothing is written.
+func (p *insertsPool) insert(ctx context.Context, ins insertValues) (bool, error) {
+	w, ok, err := p.writer(ctx, ins.target)
+	if !ok || err != nil {
+		return ok, err
+	}
+	err = w.WriteJSON(ctx, ins.row)
+	var insertErr *ksql.InsertError
+	if err != nil && ctx.Err() == nil && !(errors.As(err, &insertErr) && insertErr.Err == nil) {
+		// the stream has failed rather than the row being rejected, so it is reopened by the next insert
+		p.discard(ins.target, w)
+	}
+	return true, err
+}
+
+// writer returns the inserts stream of the target, opening it if needed. It reports false if the target isn't known to be a stream, in which case the insert is executed as a statement.
+func (p *insertsPool) writer(ctx context.Context, target string) (*ksql.InsertsStreamWriter, bool, error) {
+	p.mu.Lock()
+	w, ok, known, err := p.cached(target)
+	p.mu.Unlock()
+	if known || err != nil {
+		return w, ok, err
+	}
+	// the pool isn't locked while describing the target and opening its stream, so other inserts aren't held up
+	desc, err := p.client.Describe(ctx, target)
+	if err != nil {
+		// executing the statement reports any problem with it
+		return nil, false, nil
+	}
+	if !strings.EqualFold(desc.SourceDescription.Type, "STREAM") {
+		p.mu.Lock()
+		p.tables[target] = true
+		p.mu.Unlock()
+		return nil, false, nil
+	}
+	// the stream outlives the statement, so it isn't bound to its context
+	w, err = p.client.InsertsStream(context.Background(), ksql.InsertsStreamTargetPayload{Target: target}, ksql.WithSchema(desc.SourceDescription))
+	if err != nil {
+		return nil, false, err
+	}
+	p.mu.Lock()
+	existing, ok, known, err := p.cached(target)
+	if !known && err == nil {
+		p.writers[target] = w
+	}
+	p.mu.Unlock()
+	if known || err != nil {
+		// the pool was closed, or another insert opened a stream first
+		w.Close()
+		return existing, ok, err
+	}
+	return w, true, nil
+}
+
+// cached returns the inserts stream of the target if it is already open, reporting whether the target is known. It must be called with mu held.
+func (p *insertsPool) cached(target string) (w *ksql.InsertsStreamWriter, ok, known bool, err error) {
+	if p.writers == nil {
+		return nil, false, true, ksql.ErrWriterClosed
+	}
+	if w, ok := p.writers[target]; ok {
+		return w, true, true, nil
+	}
+	if p.tables[target] {
+		return nil, false, true, nil
+	}
+	return nil, false, false, nil
+}
+
+func (p *insertsPool) discard(target string, w *ksql.InsertsStreamWriter) {
+	p.mu.Lock()
+	if p.writers[target] == w {
+		delete(p.writers, target)
+	}
+	p.mu.Unlock()
+	w.Close()
+}
+
+// Close closes every inserts stream, returning the first error
+func (p *insertsPool) Close() error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	writers := p.writers
+	p.writers = nil
+	p.mu.Unlock()
+	var firstErr error
+	for _, w := range writers {
+		if err := w.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,231 @@
+package stdlib
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	ksql "github.com/vancelongwill/ksql-go/client"
+	"golang.org/x/net/http2"
+)
+
+func TestParseInsertValues(t *testing.T) {
+	t.Run("it should parse single row inserts with literal values", func(t *testing.T) {
+		got, ok := parseInsertValues("INSERT INTO s1 (k, v1, v2, v3, v4, v5) VALUES ('it''s', -1.5, TRUE, NULL, 10, (-2));")
+		assert.True(t, ok)
+		assert.Equal(t, insertValues{
+			target: "S1",
+			row: map[string]interface{}{
+				"K":  "it's",
+				"V1": json.Number("-1.5"),
+				"V2": true,
+				"V3": nil,
+				"V4": json.Number("10"),
+				"V5": json.Number("-2"),
+			},
+		}, got)
+	})
+
+	t.Run("it should reject other statements", func(t *testing.T) {
+		for _, sql := range []string{
+			"INSERT INTO s1 VALUES ('a', 1);",
+			"INSERT INTO s1 (k, v) VALUES ('a', ABS(-1));",
+			"INSERT INTO s1 (k, v) VALUES ('a', ARRAY[1]);",
+			"INSERT INTO s1 (k, v) VALUES ('a');",
+			"INSERT INTO s1 (k, k) VALUES ('a', 'b');",
+			"INSERT INTO `s1` (k) VALUES ('a');",
+			"INSERT INTO s1 (`k`) VALUES ('a');",
+			"INSERT INTO s1 (k) VALUES (.5);",
+			"INSERT INTO s1 (k) VALUES (-'a');",
+			"INSERT INTO s1 (k) VALUES ((1));",
+			"INSERT INTO s1 (k) VALUES ((-1);",
+			"INSERT INTO s1 (k) VALUES ((-'a'));",
+			"INSERT INTO s2 SELECT * FROM s1;",
+			"INSERT INTO s1 (k) VALUES ('a'); INSERT INTO s1 (k) VALUES ('b');",
+			"CREATE STREAM s1 (k STRING KEY) WITH (KAFKA_TOPIC='s1');",
+		} {
+			_, ok := parseInsertValues(sql)
+			assert.False(t, ok, sql)
+		}
+	})
+}
+
+// insertsServer has a stream S1 and a table T1, rejecting inserted rows with the key "rejected"
+type insertsServer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	statements []string
+	rows       []map[string]interface{}
+	streams    int
+	describes  int
+}
+
+func newInsertsServer(t *testing.T) *insertsServer {
+	s := &insertsServer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ksql", func(w http.ResponseWriter, r *http.Request) {
+		var payload ksql.ExecPayload
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		result := ksql.ExecResult{}
+		if strings.HasPrefix(payload.KSQL, "DESCRIBE ") {
+			s.mu.Lock()
+			s.describes++
+			s.mu.Unlock()
+		}
+		switch payload.KSQL {
+		case "DESCRIBE S2;":
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		case "DESCRIBE S1;":
+			result.DescribeResult = &ksql.DescribeResult{SourceDescription: ksql.SourceDescription{
+				Name: "S1",
+				Type: "STREAM",
+				Fields: []ksql.Field{
+					{Name: "K", Schema: ksql.Schema{Type: "STRING"}, Type: "KEY"},
+					{Name: "V", Schema: ksql.Schema{Type: "BIGINT"}},
+				},
+			}}
+		case "DESCRIBE T1;":
+			result.DescribeResult = &ksql.DescribeResult{SourceDescription: ksql.SourceDescription{Name: "T1", Type: "TABLE"}}
+		default:
+			s.mu.Lock()
+			s.statements = append(s.statements, payload.KSQL)
+			s.mu.Unlock()
+			result.CommandResult = &ksql.CommandResult{}
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode([]ksql.ExecResult{result}))
+	})
+	mux.HandleFunc("/inserts-stream", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.streams++
+		s.mu.Unlock()
+		dec := json.NewDecoder(r.Body)
+		var target ksql.InsertsStreamTargetPayload
+		assert.NoError(t, dec.Decode(&target))
+		assert.Equal(t, "S1", target.Target)
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		enc := json.NewEncoder(w)
+		for seq := int64(0); ; seq++ {
+			var row map[string]interface{}
+			if err := dec.Decode(&row); err != nil {
+				return
+			}
+			ack := ksql.InsertsStreamAck{Status: "ok", Seq: seq}
+			if row["K"] == "rejected" {
+				ack = ksql.InsertsStreamAck{Status: "error", Seq: seq, ErrorCode: 40000, Message: "rejected"}
+			} else {
+				s.mu.Lock()
+				s.rows = append(s.rows, row)
+				s.mu.Unlock()
+			}
+			assert.NoError(t, enc.Encode(ack))
+			w.(http.Flusher).Flush()
+		}
+	})
+	s.Server = httptest.NewUnstartedServer(mux)
+	s.EnableHTTP2 = true
+	s.StartTLS()
+	return s
+}
+
+func (s *insertsServer) conn(options ...ConnectorOption) *Conn {
+	tr := &http.Transport{}
+	if err := http2.ConfigureTransport(tr); err != nil {
+		panic(err)
+	}
+	tr.TLSClientConfig.InsecureSkipVerify = true
+	c, _ := NewConnector(ksql.New(s.URL, ksql.WithHTTPClient(&http.Client{Transport: tr})), options...).Connect(context.Background())
+	return c.(*Conn)
+}
+
+func TestInsertsStream(t *testing.T) {
+	t.Run("it should write inserts into a stream to a shared inserts stream", func(t *testing.T) {
+		srv := newInsertsServer(t)
+		defer srv.Close()
+		c := srv.conn(WithInsertsStream())
+		ctx := context.Background()
+		for _, k := range []string{"a", "b"} {
+			_, err := c.ExecContext(ctx, "INSERT INTO s1 (k, v) VALUES (?, 1);", []driver.NamedValue{{Ordinal: 1, Value: k}})
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, c.Close())
+		assert.Equal(t, 1, srv.streams)
+		assert.Equal(t, 1, srv.describes)
+		assert.Equal(t, []map[string]interface{}{{"K": "a", "V": float64(1)}, {"K": "b", "V": float64(1)}}, srv.rows)
+		assert.Empty(t, srv.statements)
+	})
+
+	t.Run("it should write negative arguments to the inserts stream", func(t *testing.T) {
+		srv := newInsertsServer(t)
+		defer srv.Close()
+		c := srv.conn(WithInsertsStream())
+		ctx := context.Background()
+		_, err := c.ExecContext(ctx, "INSERT INTO s1 (k, v) VALUES (?, ?);", []driver.NamedValue{{Ordinal: 1, Value: "a"}, {Ordinal: 2, Value: int64(-1)}})
+		assert.NoError(t, err)
+		assert.NoError(t, c.Close())
+		assert.Equal(t, []map[string]interface{}{{"K": "a", "V": float64(-1)}}, srv.rows)
+		assert.Empty(t, srv.statements)
+	})
+
+	t.Run("it should execute the insert as a statement when the target can't be described", func(t *testing.T) {
+		srv := newInsertsServer(t)
+		defer srv.Close()
+		c := srv.conn(WithInsertsStream())
+		defer c.Close()
+		_, err := c.ExecContext(context.Background(), "INSERT INTO s2 (k) VALUES ('a');", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"INSERT INTO s2 (k) VALUES ('a');"}, srv.statements)
+		assert.Equal(t, 0, srv.streams)
+	})
+
+	t.Run("it should return rejected rows as errors", func(t *testing.T) {
+		srv := newInsertsServer(t)
+		defer srv.Close()
+		c := srv.conn(WithInsertsStream())
+		defer c.Close()
+		_, err := c.ExecContext(context.Background(), "INSERT INTO s1 (k) VALUES ('rejected');", nil)
+		assert.True(t, errors.Is(err, ksql.ErrAckUnsucessful))
+		_, err = c.ExecContext(context.Background(), "INSERT INTO s1 (k) VALUES ('accepted');", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, srv.streams, "a rejected row doesn't close the stream")
+	})
+
+	t.Run("it should execute other statements", func(t *testing.T) {
+		srv := newInsertsServer(t)
+		defer srv.Close()
+		c := srv.conn(WithInsertsStream())
+		defer c.Close()
+		statements := []string{
+			"INSERT INTO t1 (k) VALUES ('a');",
+			"INSERT INTO t1 (k) VALUES ('b');",
+			"INSERT INTO s1 (k, v) VALUES ('a', ABS(-1));",
+			"DROP STREAM s2;",
+		}
+		for _, stmt := range statements {
+			_, err := c.ExecContext(context.Background(), stmt, nil)
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, statements, srv.statements)
+		assert.Equal(t, 0, srv.streams)
+	})
+
+	t.Run("it should execute inserts as statements unless enabled", func(t *testing.T) {
+		srv := newInsertsServer(t)
+		defer srv.Close()
+		c := srv.conn()
+		defer c.Close()
+		_, err := c.ExecContext(context.Background(), "INSERT INTO s1 (k) VALUES ('a');", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"INSERT INTO s1 (k) VALUES ('a');"}, srv.statements)
+		assert.Equal(t, 0, srv.streams)
+	})
+}
@@ -78,6 +78,26 @@ func (mr *MockClientMockRecorder) Exec(ctx, params interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockClient)(nil).Exec), ctx, params)
 }
 
+// ExecScript mocks base method
+func (m *MockClient) ExecScript(ctx context.Context, script string, options ...client.ExecScriptOption) ([]client.StatementResult, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, script}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ExecScript", varargs...)
+	ret0, _ := ret[0].([]client.StatementResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecScript indicates an expected call of ExecScript
+func (mr *MockClientMockRecorder) ExecScript(ctx, script interface{}, options ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, script}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecScript", reflect.TypeOf((*MockClient)(nil).ExecScript), varargs...)
+}
+
 // Explain mocks base method
 func (m *MockClient) Explain(ctx context.Context, queryNameOrExpression string) (client.ExplainResult, error) {
 	m.ctrl.T.Helper()
@@ -124,18 +144,43 @@ func (mr *MockClientMockRecorder) Info(ctx interface{}) *gomock.Call {
 }
 
 // InsertsStream mocks base method
-func (m *MockClient) InsertsStream(ctx context.Context, payload client.InsertsStreamTargetPayload) (*client.InsertsStreamWriter, error) {
+func (m *MockClient) InsertsStream(ctx context.Context, payload client.InsertsStreamTargetPayload, options ...client.InsertsStreamOption) (*client.InsertsStreamWriter, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InsertsStream", ctx, payload)
+	varargs := []interface{}{ctx, payload}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "InsertsStream", varargs...)
 	ret0, _ := ret[0].(*client.InsertsStreamWriter)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // InsertsStream indicates an expected call of InsertsStream
-func (mr *MockClientMockRecorder) InsertsStream(ctx, payload interface{}) *gomock.Call {
+func (mr *MockClientMockRecorder) InsertsStream(ctx, payload interface{}, options ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, payload}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertsStream", reflect.TypeOf((*MockClient)(nil).InsertsStream), varargs...)
+}
+
+// ResilientInsertsStream mocks base method
+func (m *MockClient) ResilientInsertsStream(ctx context.Context, payload client.InsertsStreamTargetPayload, policy client.ReconnectPolicy, options ...client.InsertsStreamOption) (*client.ResilientInsertsStreamWriter, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, payload, policy}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ResilientInsertsStream", varargs...)
+	ret0, _ := ret[0].(*client.ResilientInsertsStreamWriter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResilientInsertsStream indicates an expected call of ResilientInsertsStream
+func (mr *MockClientMockRecorder) ResilientInsertsStream(ctx, payload, policy interface{}, options ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertsStream", reflect.TypeOf((*MockClient)(nil).InsertsStream), ctx, payload)
+	varargs := append([]interface{}{ctx, payload, policy}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResilientInsertsStream", reflect.TypeOf((*MockClient)(nil).ResilientInsertsStream), varargs...)
 }
 
 // ListQueries mocks base method
@@ -228,6 +273,41 @@ func (mr *MockClientMockRecorder) QueryStream(ctx, payload interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryStream", reflect.TypeOf((*MockClient)(nil).QueryStream), ctx, payload)
 }
 
+// ResilientQueryStream mocks base method
+func (m *MockClient) ResilientQueryStream(ctx context.Context, payload client.QueryStreamPayload, policy client.ReconnectPolicy) (*client.ResilientQueryStreamRows, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResilientQueryStream", ctx, payload, policy)
+	ret0, _ := ret[0].(*client.ResilientQueryStreamRows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResilientQueryStream indicates an expected call of ResilientQueryStream
+func (mr *MockClientMockRecorder) ResilientQueryStream(ctx, payload, policy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResilientQueryStream", reflect.TypeOf((*MockClient)(nil).ResilientQueryStream), ctx, payload, policy)
+}
+
+// Subscribe mocks base method
+func (m *MockClient) Subscribe(ctx context.Context, payload client.QueryStreamPayload, options ...client.SubscribeOption) (*client.Subscription, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, payload}
+	for _, a := range options {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Subscribe", varargs...)
+	ret0, _ := ret[0].(*client.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe
+func (mr *MockClientMockRecorder) Subscribe(ctx, payload interface{}, options ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, payload}, options...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockClient)(nil).Subscribe), varargs...)
+}
+
 // CloseQuery mocks base method
 func (m *MockClient) CloseQuery(ctx context.Context, payload client.CloseQueryPayload) error {
 	m.ctrl.T.Helper()
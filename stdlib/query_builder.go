@@ -2,39 +2,86 @@ package stdlib
 
 import (
 	"database/sql/driver"
-	"encoding/json"
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
+
+	"github.com/vancelongwill/ksql-go/ksqltypes"
+	"github.com/vancelongwill/ksql-go/lexer"
 )
 
 var (
 	// ErrMissingSemicolon is returned when the sql statement is missing a trailing semi-colon
 	ErrMissingSemicolon = errors.New("statement is missing a trailing semi-colon")
+	// ErrMissingArgument is returned when a placeholder in the sql statement has no matching argument
+	ErrMissingArgument = errors.New("missing argument")
 )
 
-// @TODO: this will need revisiting to add more validation logic/be replaced with a less naive implementation
+// buildStatement binds the args to the placeholders in the statement, rendering each as a ksqlDB literal.
+//
+// Placeholders are $1 for the argument at an ordinal position, :name for a named argument, or ? for the next positional argument. Placeholders inside string literals, quoted identifiers and comments are left alone.
 func buildStatement(q string, args []driver.NamedValue) (string, error) {
-	if !strings.HasSuffix(q, ";") {
+	tokens, err := lexer.Tokenize(q)
+	if err != nil {
+		return "", err
+	}
+	if !lexer.IsTerminated(tokens) {
 		return "", ErrMissingSemicolon
 	}
 
-	var replacements []string
-
-	for _, arg := range args {
-		// value must be json serializable
-		b, err := json.Marshal(arg.Value)
-		// strings get wrapped in double quotes
-		s := strings.Trim(string(b), `"`)
+	var (
+		b    strings.Builder
+		next int
+	)
+	for _, tok := range tokens {
+		if tok.Kind != lexer.Placeholder {
+			b.WriteString(tok.Text)
+			continue
+		}
+		var (
+			arg *driver.NamedValue
+			ok  bool
+		)
+		switch tok.Text[0] {
+		case '?':
+			next++
+			arg, ok = ordinalArg(args, next)
+		case '$':
+			ordinal, err := strconv.Atoi(tok.Value())
+			if err != nil {
+				return "", fmt.Errorf("invalid placeholder %s: %w", tok.Text, err)
+			}
+			arg, ok = ordinalArg(args, ordinal)
+		case ':':
+			arg, ok = namedArg(args, tok.Value())
+		}
+		if !ok {
+			return "", fmt.Errorf("%w for %s at line %d, column %d", ErrMissingArgument, tok.Text, tok.Line, tok.Column)
+		}
+		literal, err := ksqltypes.Literal(arg.Value)
 		if err != nil {
-			return "", err
+			return "", fmt.Errorf("unable to bind %s: %w", tok.Text, err)
 		}
-		if arg.Name != "" {
-			replacements = append(replacements, ":"+arg.Name, s)
-		} else {
-			replacements = append(replacements, "$"+strconv.Itoa(arg.Ordinal), s)
+		b.WriteString(literal)
+	}
+	return b.String(), nil
+}
+
+func ordinalArg(args []driver.NamedValue, ordinal int) (*driver.NamedValue, bool) {
+	for i := range args {
+		if args[i].Name == "" && args[i].Ordinal == ordinal {
+			return &args[i], true
 		}
 	}
+	return nil, false
+}
 
-	return strings.NewReplacer(replacements...).Replace(q), nil
+func namedArg(args []driver.NamedValue, name string) (*driver.NamedValue, bool) {
+	for i := range args {
+		if args[i].Name == name {
+			return &args[i], true
+		}
+	}
+	return nil, false
 }
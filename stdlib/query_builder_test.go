@@ -2,6 +2,7 @@ package stdlib
 
 import (
 	"database/sql/driver"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -14,6 +15,14 @@ func TestQueryBuilder(t *testing.T) {
 		assert.Error(t, err)
 		assert.True(t, err == ErrMissingSemicolon)
 	})
+	t.Run("ignores comments after the trailing semi-colon", func(t *testing.T) {
+		_, err := buildStatement("LIST STREAMS; -- all of them\n", nil)
+		assert.NoError(t, err)
+	})
+	t.Run("returns an error when the semi-colon is inside a string literal", func(t *testing.T) {
+		_, err := buildStatement("SELECT * FROM t1 WHERE name = ';'", nil)
+		assert.True(t, err == ErrMissingSemicolon)
+	})
 	t.Run("replaces positional args", func(t *testing.T) {
 		t.Run("in the correct order", func(t *testing.T) {
 			got, err := buildStatement("SELECT * FROM t1 WHERE name = $1 AND age = $2;", []driver.NamedValue{
@@ -21,7 +30,7 @@ func TestQueryBuilder(t *testing.T) {
 				{Ordinal: 2, Value: 45},
 			})
 			assert.NoError(t, err)
-			assert.Equal(t, `SELECT * FROM t1 WHERE name = Bob AND age = 45;`, got)
+			assert.Equal(t, `SELECT * FROM t1 WHERE name = 'Bob' AND age = 45;`, got)
 		})
 		t.Run("by their position numbers", func(t *testing.T) {
 			got, err := buildStatement("SELECT * FROM t1 WHERE name = $2 AND age = $1;", []driver.NamedValue{
@@ -29,7 +38,7 @@ func TestQueryBuilder(t *testing.T) {
 				{Ordinal: 2, Value: "Bob"},
 			})
 			assert.NoError(t, err)
-			assert.Equal(t, `SELECT * FROM t1 WHERE name = Bob AND age = 45;`, got)
+			assert.Equal(t, `SELECT * FROM t1 WHERE name = 'Bob' AND age = 45;`, got)
 		})
 	})
 	t.Run("replaces named args", func(t *testing.T) {
@@ -39,7 +48,50 @@ func TestQueryBuilder(t *testing.T) {
 				{Ordinal: 2, Value: 45, Name: "age"},
 			})
 			assert.NoError(t, err)
-			assert.Equal(t, `SELECT * FROM t1 WHERE name = Bob AND age = 45;`, got)
+			assert.Equal(t, `SELECT * FROM t1 WHERE name = 'Bob' AND age = 45;`, got)
+		})
+	})
+	t.Run("parenthesises negative numbers so they can't start a comment", func(t *testing.T) {
+		got, err := buildStatement("SELECT * FROM t1 WHERE x = 0-$1 AND name = $2;", []driver.NamedValue{
+			{Ordinal: 1, Value: -5},
+			{Ordinal: 2, Value: "Bob"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, `SELECT * FROM t1 WHERE x = 0-(-5) AND name = 'Bob';`, got)
+	})
+	t.Run("quotes and escapes strings", func(t *testing.T) {
+		got, err := buildStatement("SELECT * FROM t1 WHERE name = $1;", []driver.NamedValue{
+			{Ordinal: 1, Value: "Bob'; DROP TABLE t1; --"},
 		})
+		assert.NoError(t, err)
+		assert.Equal(t, `SELECT * FROM t1 WHERE name = 'Bob''; DROP TABLE t1; --';`, got)
+	})
+	t.Run("doesn't confuse $1 with the prefix of $10", func(t *testing.T) {
+		args := make([]driver.NamedValue, 10)
+		for i := range args {
+			args[i] = driver.NamedValue{Ordinal: i + 1, Value: i + 1}
+		}
+		got, err := buildStatement("SELECT * FROM t1 WHERE a = $10 AND b = $1;", args)
+		assert.NoError(t, err)
+		assert.Equal(t, `SELECT * FROM t1 WHERE a = 10 AND b = 1;`, got)
+	})
+	t.Run("leaves placeholders in string literals and comments alone", func(t *testing.T) {
+		got, err := buildStatement("SELECT '$1', `:name` FROM t1 WHERE a = $1; -- $1", []driver.NamedValue{
+			{Ordinal: 1, Value: true},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT '$1', `:name` FROM t1 WHERE a = TRUE; -- $1", got)
+	})
+	t.Run("replaces question marks in order", func(t *testing.T) {
+		got, err := buildStatement("INSERT INTO s1 (a, b) VALUES (?, ?);", []driver.NamedValue{
+			{Ordinal: 1, Value: []string{"x"}},
+			{Ordinal: 2, Value: map[string]int{"k": 1}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, `INSERT INTO s1 (a, b) VALUES (ARRAY['x'], MAP('k' := 1));`, got)
+	})
+	t.Run("returns an error when an argument is missing", func(t *testing.T) {
+		_, err := buildStatement("SELECT * FROM t1 WHERE a = $2;", []driver.NamedValue{{Ordinal: 1, Value: 1}})
+		assert.True(t, errors.Is(err, ErrMissingArgument))
 	})
 }
@@ -0,0 +1,88 @@
+package stdlib
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+
+	ksql "github.com/vancelongwill/ksql-go/client"
+)
+
+// ErrNotKsqlConn is returned by Exec when the connection doesn't belong to this driver
+var ErrNotKsqlConn = errors.New("not a ksqlDB connection")
+
+// Result is the driver.Result returned by ExecContext, describing the outcome of the executed statements.
+//
+// database/sql hides driver results behind its own sql.Result, so use Exec, or ResultOf with sql.Conn.Raw, to access it.
+type Result struct {
+	// CommandID identifies the command of the last statement which created one, e.g. stream/`S1`/create. It is empty when no command was created, e.g. for a row written to an inserts stream.
+	CommandID string
+	// CommandStatus is the status of that command, including its sequence number, which can be passed to later statements to wait for it to complete
+	CommandStatus ksql.CommandStatus
+	// Warnings holds the warnings of every statement
+	Warnings []ksql.Warning
+	// Results holds the result of each statement as returned by the client
+	Results []ksql.ExecResult
+}
+
+func newResult(results []ksql.ExecResult) *Result {
+	r := &Result{Results: results}
+	for _, res := range results {
+		r.Warnings = append(r.Warnings, res.Warnings...)
+		if res.CommandResult != nil && res.CommandID != "" {
+			r.CommandID = res.CommandID
+			r.CommandStatus = res.CommandStatus
+		}
+	}
+	return r
+}
+
+// CommandSequenceNumber returns the sequence number of the command, or zero if there is no command
+func (r *Result) CommandSequenceNumber() int64 {
+	return r.CommandStatus.CommandSequenceNumber
+}
+
+// LastInsertId is a placeholder for compatibility, as ksqlDB has no auto-incrementing IDs
+func (r *Result) LastInsertId() (int64, error) {
+	return 0, nil
+}
+
+// RowsAffected is a placeholder for compatibility, as ksqlDB doesn't report the number of affected rows
+func (r *Result) RowsAffected() (int64, error) {
+	return 0, nil
+}
+
+// ResultOf returns the ksqlDB result of a driver.Result returned by Conn.ExecContext, e.g. from within sql.Conn.Raw
+func ResultOf(r driver.Result) (*Result, bool) {
+	res, ok := r.(*Result)
+	return res, ok
+}
+
+// Exec executes a statement on a database/sql connection to ksqlDB in the same way as ExecContext, returning its ksqlDB result
+func Exec(ctx context.Context, conn *sql.Conn, query string, args ...interface{}) (*Result, error) {
+	var res *Result
+	err := conn.Raw(func(driverConn interface{}) error {
+		c, ok := driverConn.(*Conn)
+		if !ok {
+			return ErrNotKsqlConn
+		}
+		named := make([]driver.NamedValue, len(args))
+		for i, arg := range args {
+			named[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+			if n, ok := arg.(sql.NamedArg); ok {
+				named[i].Name = n.Name
+				named[i].Value = n.Value
+			}
+		}
+		r, err := c.ExecContext(ctx, query, named)
+		if err != nil {
+			return err
+		}
+		res, _ = ResultOf(r)
+		return nil
+	})
+	return res, err
+}
+
+var _ driver.Result = &Result{}
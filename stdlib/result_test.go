@@ -0,0 +1,91 @@
+package stdlib
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	ksql "github.com/vancelongwill/ksql-go/client"
+	"github.com/vancelongwill/ksql-go/stdlib/mocks"
+)
+
+func execResults() []ksql.ExecResult {
+	list := ksql.ExecResult{ListStreamsResult: &ksql.ListStreamsResult{}}
+	list.Warnings = []ksql.Warning{{Message: "first warning"}}
+	create := ksql.ExecResult{CommandResult: &ksql.CommandResult{
+		CommandID: "stream/`S1`/create",
+		CommandStatus: ksql.CommandStatus{
+			Status:                "SUCCESS",
+			Message:               "Stream created",
+			CommandSequenceNumber: 42,
+		},
+	}}
+	create.Warnings = []ksql.Warning{{Message: "second warning"}}
+	return []ksql.ExecResult{list, create}
+}
+
+func TestResult(t *testing.T) {
+	t.Run("it should describe the last command and collect every warning", func(t *testing.T) {
+		results := execResults()
+		r := newResult(results)
+		assert.Equal(t, "stream/`S1`/create", r.CommandID)
+		assert.Equal(t, "SUCCESS", r.CommandStatus.Status)
+		assert.Equal(t, int64(42), r.CommandSequenceNumber())
+		assert.Equal(t, []ksql.Warning{{Message: "first warning"}, {Message: "second warning"}}, r.Warnings)
+		assert.Equal(t, results, r.Results)
+	})
+
+	t.Run("it should be empty when no command was created", func(t *testing.T) {
+		r := newResult(nil)
+		assert.Empty(t, r.CommandID)
+		assert.Zero(t, r.CommandSequenceNumber())
+		id, err := r.LastInsertId()
+		assert.NoError(t, err)
+		assert.Zero(t, id)
+		n, err := r.RowsAffected()
+		assert.NoError(t, err)
+		assert.Zero(t, n)
+	})
+
+	t.Run("it should be returned by ExecContext", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockClient := mocks.NewMockClient(ctrl)
+		c := newConn(mockClient)
+		mockClient.EXPECT().
+			Exec(gomock.Any(), gomock.Any()).
+			Return(execResults(), nil)
+		res, err := c.ExecContext(context.Background(), "CREATE STREAM s1 (k VARCHAR KEY) WITH (kafka_topic='s1', value_format='json');", nil)
+		assert.NoError(t, err)
+		r, ok := ResultOf(res)
+		assert.True(t, ok)
+		assert.Equal(t, int64(42), r.CommandSequenceNumber())
+	})
+}
+
+func TestExec(t *testing.T) {
+	t.Run("it should return the result of the statement", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		mockClient := mocks.NewMockClient(ctrl)
+		mockClient.EXPECT().
+			Exec(gomock.Any(), ksql.ExecPayload{KSQL: "INSERT INTO s1 (k, v) VALUES ('a', 1);"}).
+			Return(execResults(), nil)
+		mockClient.EXPECT().Close().Return(nil).AnyTimes()
+
+		db := sql.OpenDB(NewConnector(mockClient))
+		defer db.Close()
+		ctx := context.Background()
+		conn, err := db.Conn(ctx)
+		assert.NoError(t, err)
+		defer conn.Close()
+
+		r, err := Exec(ctx, conn, "INSERT INTO s1 (k, v) VALUES (:k, :v);", sql.Named("k", "a"), sql.Named("v", 1))
+		if assert.NoError(t, err) {
+			assert.Equal(t, "stream/`S1`/create", r.CommandID)
+			assert.Len(t, r.Warnings, 2)
+		}
+	})
+}
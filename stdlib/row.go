@@ -2,12 +2,22 @@ package stdlib
 
 import (
 	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
 
 	ksql "github.com/vancelongwill/ksql-go/client"
+	"github.com/vancelongwill/ksql-go/ksqltypes"
 )
 
 type rowWrapper struct {
 	rows ksql.Rows
+	// release is called once the rows are closed, to stop tracking them
+	release func()
 }
 
 func (q *rowWrapper) Columns() []string {
@@ -15,6 +25,9 @@ func (q *rowWrapper) Columns() []string {
 }
 
 func (q *rowWrapper) Close() error {
+	if q.release != nil {
+		q.release()
+	}
 	return q.rows.Close()
 }
 
@@ -24,7 +37,162 @@ func (q *rowWrapper) Next(dest []driver.Value) error {
 		return err
 	}
 	for i, col := range in {
-		dest[i] = col.(driver.Value)
+		v, err := q.convert(i, col)
+		if err != nil {
+			return fmt.Errorf("column %d: %w", i, err)
+		}
+		dest[i] = v
 	}
 	return nil
 }
+
+// convert turns a decoded JSON value into a valid driver.Value
+func (q *rowWrapper) convert(index int, v interface{}) (driver.Value, error) {
+	switch x := v.(type) {
+	case json.Number:
+		// the client keeps numbers exact, so integer columns are parsed as int64, and decimals are passed as strings for ksqltypes.Decimal
+		switch typ := q.ColumnTypeDatabaseTypeName(index); {
+		case isIntegerType(typ):
+			return x.Int64()
+		case typ == "DECIMAL":
+			return q.formatDecimal(index, x)
+		}
+		return x.Float64()
+	case float64:
+		// clients without ksql.WithUseNumber decode numbers as float64, which are converted in the same way but may be inexact
+		switch typ := q.ColumnTypeDatabaseTypeName(index); {
+		case isIntegerType(typ):
+			return int64(x), nil
+		case typ == "DECIMAL":
+			prec := -1
+			if _, scale, ok := q.ColumnTypePrecisionScale(index); ok {
+				prec = int(scale)
+			}
+			return strconv.FormatFloat(x, 'f', prec, 64), nil
+		}
+	case []interface{}, map[string]interface{}:
+		// ARRAY, MAP and STRUCT values are passed as JSON, which ksqltypes.Array, Map and Struct scan
+		return json.Marshal(x)
+	}
+	return v, nil
+}
+
+// formatDecimal formats a DECIMAL value exactly, with as many digits after the point as the scale of the column
+func (q *rowWrapper) formatDecimal(index int, n json.Number) (driver.Value, error) {
+	_, scale, ok := q.ColumnTypePrecisionScale(index)
+	if !ok {
+		return n.String(), nil
+	}
+	r, ok := new(big.Rat).SetString(n.String())
+	if !ok {
+		return nil, fmt.Errorf("invalid DECIMAL %s", n)
+	}
+	return r.FloatString(int(scale)), nil
+}
+
+func isIntegerType(typ string) bool {
+	return typ == "INTEGER" || typ == "INT" || typ == "BIGINT"
+}
+
+// columnType returns the full ksqlDB type of the column at index, e.g. 'DECIMAL(10, 2)' or 'ARRAY<STRING>', if known
+func (q *rowWrapper) columnType(index int) string {
+	typer, ok := q.rows.(ksql.ColumnTyper)
+	if !ok {
+		return ""
+	}
+	types := typer.ColumnTypes()
+	if index < 0 || index >= len(types) {
+		return ""
+	}
+	return types[index]
+}
+
+// ColumnTypeDatabaseTypeName returns the ksqlDB type name of the column without any parameters, e.g. 'DECIMAL' or 'ARRAY'
+func (q *rowWrapper) ColumnTypeDatabaseTypeName(index int) string {
+	typ := q.columnType(index)
+	if i := strings.IndexAny(typ, "(<"); i >= 0 {
+		typ = typ[:i]
+	}
+	return strings.ToUpper(strings.TrimSpace(typ))
+}
+
+var (
+	stringType    = reflect.TypeOf("")
+	anyType       = reflect.TypeOf((*interface{})(nil)).Elem()
+	scanTypeNames = map[string]reflect.Type{
+		"BOOLEAN": reflect.TypeOf(false),
+		// every integer column is returned as int64, as database/sql expects
+		"INT":     reflect.TypeOf(int64(0)),
+		"INTEGER": reflect.TypeOf(int64(0)),
+		"BIGINT":  reflect.TypeOf(int64(0)),
+		"DOUBLE":  reflect.TypeOf(float64(0)),
+		"DECIMAL": reflect.TypeOf(ksqltypes.Decimal("")),
+		// BYTES are base64 encoded, and TIMESTAMP, DATE and TIME values are formatted as strings
+		"STRING":    stringType,
+		"VARCHAR":   stringType,
+		"BYTES":     stringType,
+		"TIMESTAMP": stringType,
+		"DATE":      stringType,
+		"TIME":      stringType,
+		"ARRAY":     reflect.TypeOf(ksqltypes.Array[interface{}]{}),
+		"MAP":       reflect.TypeOf(ksqltypes.Map[string, interface{}]{}),
+		"STRUCT":    reflect.TypeOf(ksqltypes.Struct{}),
+	}
+)
+
+// ColumnTypeScanType returns the Go type of the values of the column, or interface{} if its type is unknown
+func (q *rowWrapper) ColumnTypeScanType(index int) reflect.Type {
+	if t, ok := scanTypeNames[q.ColumnTypeDatabaseTypeName(index)]; ok {
+		return t
+	}
+	return anyType
+}
+
+// ColumnTypeNullable reports that every column of a known type is nullable, as ksqlDB has no NOT NULL constraint
+func (q *rowWrapper) ColumnTypeNullable(index int) (nullable, ok bool) {
+	if q.ColumnTypeDatabaseTypeName(index) == "" {
+		return false, false
+	}
+	return true, true
+}
+
+// ColumnTypePrecisionScale returns the precision and scale of DECIMAL columns
+func (q *rowWrapper) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	if q.ColumnTypeDatabaseTypeName(index) != "DECIMAL" {
+		return 0, 0, false
+	}
+	typ := q.columnType(index)
+	open, end := strings.Index(typ, "("), strings.LastIndex(typ, ")")
+	if open < 0 || end < open {
+		return 0, 0, false
+	}
+	p, s, found := strings.Cut(typ[open+1:end], ",")
+	precision, err := strconv.ParseInt(strings.TrimSpace(p), 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if found {
+		if scale, err = strconv.ParseInt(strings.TrimSpace(s), 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	return precision, scale, true
+}
+
+// ColumnTypeLength reports that STRING and BYTES columns have no length limit
+func (q *rowWrapper) ColumnTypeLength(index int) (length int64, ok bool) {
+	switch q.ColumnTypeDatabaseTypeName(index) {
+	case "STRING", "VARCHAR", "BYTES":
+		return math.MaxInt64, true
+	}
+	return 0, false
+}
+
+var (
+	_ driver.Rows                           = &rowWrapper{}
+	_ driver.RowsColumnTypeDatabaseTypeName = &rowWrapper{}
+	_ driver.RowsColumnTypeScanType         = &rowWrapper{}
+	_ driver.RowsColumnTypeNullable         = &rowWrapper{}
+	_ driver.RowsColumnTypePrecisionScale   = &rowWrapper{}
+	_ driver.RowsColumnTypeLength           = &rowWrapper{}
+)
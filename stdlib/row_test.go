@@ -0,0 +1,192 @@
+package stdlib
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	ksql "github.com/vancelongwill/ksql-go/client"
+	"github.com/vancelongwill/ksql-go/ksqltypes"
+	"golang.org/x/net/http2"
+)
+
+// untypedRows implements ksql.Rows but not ksql.ColumnTyper
+type untypedRows struct{}
+
+func (r *untypedRows) Columns() []string {
+	return nil
+}
+
+func (r *untypedRows) Close() error {
+	return nil
+}
+
+func (r *untypedRows) Next(dest []interface{}) error {
+	return nil
+}
+
+type typedRows struct {
+	untypedRows
+	types  []string
+	values []interface{}
+}
+
+func (r *typedRows) Next(dest []interface{}) error {
+	copy(dest, r.values)
+	return nil
+}
+
+func (r *typedRows) ColumnTypes() []string {
+	return r.types
+}
+
+func TestRowWrapper(t *testing.T) {
+	t.Run("ColumnTypeDatabaseTypeName", func(t *testing.T) {
+		t.Run("when the rows report their column types", func(t *testing.T) {
+			rows := &rowWrapper{rows: &typedRows{types: []string{"STRING", "DECIMAL(10, 2)", "ARRAY<STRING>", "STRUCT<`A` INTEGER>"}}}
+			var got []string
+			for i := 0; i < 4; i++ {
+				got = append(got, rows.ColumnTypeDatabaseTypeName(i))
+			}
+			assert.Equal(t, []string{"STRING", "DECIMAL", "ARRAY", "STRUCT"}, got)
+			assert.Empty(t, rows.ColumnTypeDatabaseTypeName(4), "out of range columns have no type")
+		})
+		t.Run("when the rows don't report their column types", func(t *testing.T) {
+			rows := &rowWrapper{rows: &untypedRows{}}
+			assert.Empty(t, rows.ColumnTypeDatabaseTypeName(0))
+		})
+	})
+
+	types := []string{"INTEGER", "BIGINT", "DOUBLE", "DECIMAL(10, 2)", "STRING", "BYTES", "TIMESTAMP", "ARRAY<INTEGER>", "MAP<STRING, INTEGER>", "STRUCT<`A` INTEGER>", "GEOMETRY"}
+
+	t.Run("ColumnTypeScanType", func(t *testing.T) {
+		rows := &rowWrapper{rows: &typedRows{types: types}}
+		var got []reflect.Type
+		for i := range types {
+			got = append(got, rows.ColumnTypeScanType(i))
+		}
+		assert.Equal(t, []reflect.Type{
+			reflect.TypeOf(int64(0)),
+			reflect.TypeOf(int64(0)),
+			reflect.TypeOf(float64(0)),
+			reflect.TypeOf(ksqltypes.Decimal("")),
+			reflect.TypeOf(""),
+			reflect.TypeOf(""),
+			reflect.TypeOf(""),
+			reflect.TypeOf(ksqltypes.Array[interface{}]{}),
+			reflect.TypeOf(ksqltypes.Map[string, interface{}]{}),
+			reflect.TypeOf(ksqltypes.Struct{}),
+			reflect.TypeOf((*interface{})(nil)).Elem(),
+		}, got)
+	})
+
+	t.Run("ColumnTypeNullable", func(t *testing.T) {
+		rows := &rowWrapper{rows: &typedRows{types: types}}
+		nullable, ok := rows.ColumnTypeNullable(0)
+		assert.True(t, nullable)
+		assert.True(t, ok)
+		nullable, ok = rows.ColumnTypeNullable(len(types))
+		assert.False(t, nullable)
+		assert.False(t, ok, "columns of unknown types have unknown nullability")
+	})
+
+	t.Run("ColumnTypePrecisionScale", func(t *testing.T) {
+		rows := &rowWrapper{rows: &typedRows{types: []string{"DECIMAL(10, 2)", "DECIMAL(5)", "DOUBLE", "DECIMAL"}}}
+		precision, scale, ok := rows.ColumnTypePrecisionScale(0)
+		assert.Equal(t, []interface{}{int64(10), int64(2), true}, []interface{}{precision, scale, ok})
+		precision, scale, ok = rows.ColumnTypePrecisionScale(1)
+		assert.Equal(t, []interface{}{int64(5), int64(0), true}, []interface{}{precision, scale, ok})
+		_, _, ok = rows.ColumnTypePrecisionScale(2)
+		assert.False(t, ok)
+		_, _, ok = rows.ColumnTypePrecisionScale(3)
+		assert.False(t, ok, "a DECIMAL without parameters has an unknown precision")
+	})
+
+	t.Run("ColumnTypeLength", func(t *testing.T) {
+		rows := &rowWrapper{rows: &typedRows{types: types}}
+		length, ok := rows.ColumnTypeLength(4)
+		assert.Equal(t, int64(math.MaxInt64), length)
+		assert.True(t, ok)
+		_, ok = rows.ColumnTypeLength(0)
+		assert.False(t, ok)
+	})
+
+	t.Run("Next", func(t *testing.T) {
+		t.Run("it should convert integer columns to int64", func(t *testing.T) {
+			rows := &rowWrapper{rows: &typedRows{
+				types:  []string{"INTEGER", "BIGINT", "DOUBLE", "INTEGER"},
+				values: []interface{}{float64(1), float64(2), float64(3), nil},
+			}}
+			dest := make([]driver.Value, 4)
+			assert.NoError(t, rows.Next(dest))
+			assert.Equal(t, []driver.Value{int64(1), int64(2), float64(3), nil}, dest)
+		})
+		t.Run("it should convert composite and decimal columns for the ksqltypes scanners", func(t *testing.T) {
+			rows := &rowWrapper{rows: &typedRows{
+				types: []string{"ARRAY<INTEGER>", "MAP<STRING, DOUBLE>", "STRUCT<`CITY` STRING>", "DECIMAL(10, 2)"},
+				values: []interface{}{
+					[]interface{}{float64(1), float64(2)},
+					map[string]interface{}{"a": 1.5},
+					map[string]interface{}{"CITY": "London"},
+					float64(12.3),
+				},
+			}}
+			dest := make([]driver.Value, 4)
+			assert.NoError(t, rows.Next(dest))
+
+			var (
+				arr ksqltypes.Array[int32]
+				m   ksqltypes.Map[string, float64]
+				s   ksqltypes.Struct
+				d   ksqltypes.Decimal
+			)
+			for i, scanner := range []sql.Scanner{&arr, &m, &s, &d} {
+				assert.NoError(t, scanner.Scan(dest[i]))
+			}
+			assert.Equal(t, ksqltypes.Array[int32]{1, 2}, arr)
+			assert.Equal(t, ksqltypes.Map[string, float64]{"a": 1.5}, m)
+			assert.Equal(t, ksqltypes.Struct{"CITY": "London"}, s)
+			assert.Equal(t, ksqltypes.Decimal("12.30"), d)
+		})
+		t.Run("it should keep json.Number values exact", func(t *testing.T) {
+			rows := &rowWrapper{rows: &typedRows{
+				types:  []string{"BIGINT", "DECIMAL(38, 2)", "DOUBLE", "ARRAY<BIGINT>"},
+				values: []interface{}{json.Number("9007199254740993"), json.Number("123456789012345678901234567890.1"), json.Number("1.5"), []interface{}{json.Number("9007199254740993")}},
+			}}
+			dest := make([]driver.Value, 4)
+			assert.NoError(t, rows.Next(dest))
+			assert.Equal(t, []driver.Value{int64(9007199254740993), "123456789012345678901234567890.10", 1.5, []byte("[9007199254740993]")}, dest)
+		})
+	})
+}
+
+func TestQueryNumbers(t *testing.T) {
+	t.Run("it should scan values which a float64 can't represent exactly", func(t *testing.T) {
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`[{"header":{"queryId":"q1","schema":"` + "`ID` BIGINT, `PRICE` DECIMAL(38, 18)" + `"}},` +
+				`{"row":{"columns":[9007199254740993,12345678901234567890.123456789012345678]}}]`))
+		}))
+		srv.EnableHTTP2 = true
+		srv.StartTLS()
+		defer srv.Close()
+		tr := &http.Transport{}
+		assert.NoError(t, http2.ConfigureTransport(tr))
+		tr.TLSClientConfig.InsecureSkipVerify = true
+		db := sql.OpenDB(NewConnector(ksql.New(srv.URL, ksql.WithHTTPClient(&http.Client{Transport: tr}), ksql.WithUseNumber())))
+		defer db.Close()
+
+		var (
+			id    int64
+			price ksqltypes.Decimal
+		)
+		assert.NoError(t, db.QueryRow("SELECT * FROM t1 WHERE id = 1;").Scan(&id, &price))
+		assert.Equal(t, int64(9007199254740993), id)
+		assert.Equal(t, ksqltypes.Decimal("12345678901234567890.123456789012345678"), price)
+	})
+}
@@ -25,7 +25,7 @@ func (p PreparedStatement) QueryContext(ctx context.Context, args []driver.Named
 func convertDriverValues(vals []driver.Value) []driver.NamedValue {
 	nv := make([]driver.NamedValue, len(vals))
 	for i, v := range vals {
-		nv[i] = driver.NamedValue{Ordinal: i, Value: v}
+		nv[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
 	}
 	return nv
 }
@@ -40,9 +40,9 @@ func (p PreparedStatement) Query(args []driver.Value) (driver.Rows, error) {
 	return p.QueryContext(context.Background(), convertDriverValues(args))
 }
 
-// Close closes the ksqlDB connection
+// Close is a placeholder for compatibility, as a prepared statement holds no resources of its own. Streams opened by its queries are closed with their rows or their connection.
 func (p PreparedStatement) Close() error {
-	return p.conn.Close()
+	return nil
 }
 
 // NumInput always returns -1 to allow any number of arguments